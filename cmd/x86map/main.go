@@ -0,0 +1,369 @@
+// Command x86map reads an 8086 opcode description (mnemonic, encoding
+// shape, opcode bit pattern) from a CSV file and emits decoder/tables.go:
+// the []instFormat decode table that decoder package used to maintain by
+// hand. This mirrors how golang.org/x/arch's ppc64map turns a CSV of
+// PowerPC encodings into a generated decode table, so contributors add an
+// opcode by editing x86.csv instead of writing another branch of decode
+// logic.
+//
+// It also reads registers.csv and ea.csv, the reg-field-to-register-name
+// and rm-field-to-effective-address-registers mappings, and emits them as
+// decoder/regtables.go's lookup arrays, so those two tables are likewise
+// data rather than the hand-written switches they used to be.
+//
+// Regenerate with `go generate ./decoder/...` after editing any of the
+// three CSVs.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// row is one opcode description: which instFormat constructor to call
+// (shape), the mnemonic to pass it (blank when the opcode byte doesn't
+// determine the mnemonic on its own), and the opcode's bit pattern.
+type row struct {
+	shape    string
+	mnemonic string
+	pattern  string
+}
+
+// shapeConstructors maps a CSV row's shape column to the decoder package
+// constructor (see decoder/instformat.go) that builds its instFormat.
+var shapeConstructors = map[string]string{
+	"noop":        "noOpEntry",
+	"retimm":      "retImmEntry",
+	"directjump":  "directJumpEntry",
+	"reljump":     "relJumpEntry",
+	"fardirect":   "farDirectEntry",
+	"group1":      "group1Entry",
+	"groupfe":     "groupFEEntry",
+	"groupff":     "groupFFEntry",
+	"popregmem":   "popRegMemEntry",
+	"accmemload":  "accMemLoadEntry",
+	"accmemstore": "accMemStoreEntry",
+	"immtoregmem": "immToRegMemEntry",
+	"xchgregmem":  "xchgRegMemEntry",
+	"immacc":      "immToAccEntry",
+	"modregrm":    "modRegRMEntry",
+	"reginop":     "regInOpEntry",
+	"xchgaxreg":   "xchgAxRegEntry",
+	"immtoreg":    "immToRegEntry",
+}
+
+// noMnemonicShapes are the constructors that don't take a mnemonic
+// argument: group1/groupfe/groupff resolve their mnemonic from the
+// mod/reg/rm byte's reg field at decode time, and xchgaxreg/immtoreg have
+// a fixed mnemonic baked in.
+var noMnemonicShapes = map[string]bool{
+	"group1": true, "groupfe": true, "groupff": true,
+	"xchgaxreg": true, "immtoreg": true,
+}
+
+func main() {
+	csvPath := flag.String("csv", "x86.csv", "path to the opcode description CSV")
+	outPath := flag.String("out", "tables.go", "path to write the generated Go source to")
+	registersPath := flag.String("registers-csv", "registers.csv", "path to the register-field-to-name CSV")
+	eaPath := flag.String("ea-csv", "ea.csv", "path to the rm-field-to-effective-address CSV")
+	regsOutPath := flag.String("regs-out", "regtables.go", "path to write the generated register/effective-address tables to")
+	flag.Parse()
+
+	rows, err := readRows(*csvPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	source, err := generate(rows)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*outPath, []byte(source), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	registerRows, err := readRegisterRows(*registersPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	eaRows, err := readEARows(*eaPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	regsSource, err := generateRegTables(registerRows, eaRows)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*regsOutPath, []byte(regsSource), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readRows(path string) ([]row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []row
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "shape") {
+			continue // header
+		}
+		rows = append(rows, row{
+			shape:    strings.TrimSpace(record[0]),
+			mnemonic: strings.TrimSpace(record[1]),
+			pattern:  strings.TrimSpace(record[2]),
+		})
+	}
+	return rows, nil
+}
+
+// patternMaskValue reads an 8-bit opcode bit pattern - '0'/'1' for a bit
+// the opcode byte fixes, any other character (mod/reg/rm/w/d/s's
+// placeholder letters: w, d, s, r, ...) for a bit the mod/reg/rm byte or
+// further decoding determines - and returns the mask/value pair that
+// matches it: mask has a 1 in every fixed bit position, value holds those
+// bits' literal 0/1.
+func patternMaskValue(pattern string) (mask, value byte, err error) {
+	bits := strings.ReplaceAll(pattern, "_", "")
+	if len(bits) != 8 {
+		return 0, 0, fmt.Errorf("pattern %q must have 8 bits, got %d", pattern, len(bits))
+	}
+	for i, c := range bits {
+		bit := byte(1) << (7 - i)
+		switch c {
+		case '0':
+			mask |= bit
+		case '1':
+			mask |= bit
+			value |= bit
+		default:
+			// a placeholder bit (w, d, s, r, ...): left free in both mask
+			// and value, so it matches either 0 or 1.
+		}
+	}
+	return mask, value, nil
+}
+
+// registerRow is one entry of registers.csv: a 3-bit reg/rm field value,
+// whether it names a word or byte register, and the register's assembly
+// name.
+type registerRow struct {
+	field byte
+	wide  bool
+	name  string
+}
+
+// eaRow is one entry of ea.csv: a mod/reg/rm byte's r/m field (mod != 11)
+// and the base/index registers it selects for the effective address.
+type eaRow struct {
+	rm   byte
+	regs []string
+}
+
+func readRegisterRows(path string) ([]registerRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []registerRow
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "field") {
+			continue // header
+		}
+		field, err := parseBits(strings.TrimSpace(record[0]), 3)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: bad field %q: %w", i+1, record[0], err)
+		}
+		wide, err := parseWide(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		rows = append(rows, registerRow{field: field, wide: wide, name: strings.TrimSpace(record[2])})
+	}
+	return rows, nil
+}
+
+func readEARows(path string) ([]eaRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []eaRow
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "rm") {
+			continue // header
+		}
+		rm, err := parseBits(strings.TrimSpace(record[0]), 3)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: bad rm %q: %w", i+1, record[0], err)
+		}
+		rows = append(rows, eaRow{rm: rm, regs: strings.Split(strings.TrimSpace(record[1]), "+")})
+	}
+	return rows, nil
+}
+
+// parseBits parses a literal '0'/'1' bit string of exactly width bits.
+func parseBits(bits string, width int) (byte, error) {
+	if len(bits) != width {
+		return 0, fmt.Errorf("must have %d bits, got %d", width, len(bits))
+	}
+	var value byte
+	for _, c := range bits {
+		value <<= 1
+		switch c {
+		case '0':
+		case '1':
+			value |= 1
+		default:
+			return 0, fmt.Errorf("not a literal bit: %q", c)
+		}
+	}
+	return value, nil
+}
+
+func parseWide(s string) (bool, error) {
+	switch s {
+	case "word":
+		return true, nil
+	case "byte":
+		return false, nil
+	}
+	return false, fmt.Errorf("wide column must be \"word\" or \"byte\", got %q", s)
+}
+
+// generateRegTables emits decoder/regtables.go: the reg-field and
+// rm-field lookup arrays operands.go and byteToRegisterString use, in
+// field order so each array can be indexed directly by the 3-bit field
+// value rather than switched on.
+func generateRegTables(registers []registerRow, ea []eaRow) (string, error) {
+	namesW1, err := orderByField(registers, true)
+	if err != nil {
+		return "", err
+	}
+	namesW0, err := orderByField(registers, false)
+	if err != nil {
+		return "", err
+	}
+	eaRegs, err := orderEAByField(ea)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by cmd/x86map from registers.csv and ea.csv; DO NOT EDIT.\n\n")
+	b.WriteString("package decoder\n\n")
+	b.WriteString("// registerNamesW1/registerNamesW0 map a mod/reg/rm field's register\n")
+	b.WriteString("// selector (0-7) to its assembly name, word-sized (W1) or byte-sized (W0).\n")
+	fmt.Fprintf(&b, "var registerNamesW1 = [8]string{%s}\n", quoteJoin(namesW1[:]))
+	fmt.Fprintf(&b, "var registerNamesW0 = [8]string{%s}\n\n", quoteJoin(namesW0[:]))
+	b.WriteString("// effectiveAddressRegs maps a mod/reg/rm byte's r/m field (mod != 11) to\n")
+	b.WriteString("// the base/index registers that combine to form the effective address;\n")
+	b.WriteString("// rm=110 with mod=00 is the direct-address special case and has no entry\n")
+	b.WriteString("// here (memBaseRegs's caller handles it separately).\n")
+	b.WriteString("var effectiveAddressRegs = [8][]string{\n")
+	for _, regs := range eaRegs {
+		fmt.Fprintf(&b, "\t{%s},\n", quoteJoin(regs))
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+func orderByField(rows []registerRow, wide bool) ([8]string, error) {
+	var names [8]string
+	var seen [8]bool
+	for _, r := range rows {
+		if r.wide != wide {
+			continue
+		}
+		names[r.field] = r.name
+		seen[r.field] = true
+	}
+	for field, ok := range seen {
+		if !ok {
+			return names, fmt.Errorf("registers.csv: no entry for field %03b wide=%v", field, wide)
+		}
+	}
+	return names, nil
+}
+
+func orderEAByField(rows []eaRow) ([8][]string, error) {
+	var regs [8][]string
+	var seen [8]bool
+	for _, r := range rows {
+		regs[r.rm] = r.regs
+		seen[r.rm] = true
+	}
+	for field, ok := range seen {
+		if !ok {
+			return regs, fmt.Errorf("ea.csv: no entry for rm %03b", field)
+		}
+	}
+	return regs, nil
+}
+
+func quoteJoin(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func generate(rows []row) (string, error) {
+	var b strings.Builder
+	b.WriteString("// Code generated by cmd/x86map from x86.csv; DO NOT EDIT.\n\n")
+	b.WriteString("package decoder\n\n")
+	b.WriteString("var instFormats = []instFormat{\n")
+	for _, r := range rows {
+		ctor, ok := shapeConstructors[r.shape]
+		if !ok {
+			return "", fmt.Errorf("unknown shape %q for pattern %q", r.shape, r.pattern)
+		}
+		mask, value, err := patternMaskValue(r.pattern)
+		if err != nil {
+			return "", fmt.Errorf("shape %q: %w", r.shape, err)
+		}
+		if noMnemonicShapes[r.shape] {
+			fmt.Fprintf(&b, "\t%s(0x%02X, 0x%02X),\n", ctor, mask, value)
+		} else {
+			fmt.Fprintf(&b, "\t%s(0x%02X, 0x%02X, %q),\n", ctor, mask, value, r.mnemonic)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
@@ -28,27 +28,13 @@ const (
 	DI RegisterW1 = 0b111
 )
 
+// byteToRegisterW1String looks value up in registerNamesW1 (decoder/regtables.go,
+// generated from cmd/x86map/registers.csv).
 func byteToRegisterW1String(value byte) string {
-	switch value {
-	case byte(AX):
-		return "ax"
-	case byte(CX):
-		return "cx"
-	case byte(DX):
-		return "dx"
-	case byte(BX):
-		return "bx"
-	case byte(SP):
-		return "sp"
-	case byte(BP):
-		return "bp"
-	case byte(SI):
-		return "si"
-	case byte(DI):
-		return "di"
+	if int(value) >= len(registerNamesW1) {
+		return ""
 	}
-
-	return ""
+	return registerNamesW1[value]
 }
 
 // RegisterW0 when W = 0, (Instruction operates on byte data instead of word data)
@@ -65,25 +51,11 @@ const (
 	BH RegisterW0 = 0b111
 )
 
+// byteToRegisterW0String looks value up in registerNamesW0 (decoder/regtables.go,
+// generated from cmd/x86map/registers.csv).
 func byteToRegisterW0String(value byte) string {
-	switch value {
-	case byte(AL):
-		return "al"
-	case byte(CL):
-		return "cl"
-	case byte(DL):
-		return "dl"
-	case byte(BL):
-		return "bl"
-	case byte(AH):
-		return "ah"
-	case byte(CH):
-		return "ch"
-	case byte(DH):
-		return "dh"
-	case byte(BH):
-		return "bh"
+	if int(value) >= len(registerNamesW0) {
+		return ""
 	}
-
-	return ""
+	return registerNamesW0[value]
 }
@@ -4,456 +4,201 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
 )
 
 // Details of the mov operation are after page 160 of 8086 user's manual
 
-// Reads an array of binary instructions and iterates over each instruction.
+// maxInstructionLength is the longest instruction this decoder can
+// produce today: group 1's 0x81 immediate-arithmetic opcode with a
+// 16-bit displacement and a 16-bit immediate (opcode + mod/reg/rm +
+// disp16 + imm16). Decode peeks this many bytes ahead at a time; widen it
+// if a longer encoding (segment override/prefix bytes) is ever added.
+const maxInstructionLength = 6
+
+// Decoder reads a stream of 8086 machine code and decodes it one
+// instruction at a time via Decode. Construct one with NewDecoder for a
+// streaming io.Reader source, or NewDecoderBytes when the whole program
+// is already in memory.
 type Decoder struct {
-	Data []byte
-	pos  int
+	r        io.Reader
+	buf      []byte
+	bufStart int // stream offset of buf[0]; advances as peek drops consumed bytes on a NewDecoder source
+	pos      int
+	readErr  error // sticky error from r, once one occurs; io.EOF once r is drained
+
+	// syntax selects how AsmString/AsmStringAt render a decoded
+	// instruction; it defaults to SyntaxIntel (the zero value) and is
+	// changed via SetSyntax.
+	syntax Syntax
 }
 
-func (d *Decoder) HasNext() bool {
-	return (d.pos + 1) < len(d.Data)
+// NewDecoder returns a Decoder that reads machine code lazily from r,
+// buffering only as many bytes ahead of the current instruction as it
+// needs to decode it. It forgets bytes behind the current position as it
+// goes, so decoding a plain forward pass over r costs memory bounded by
+// peek's 4096-byte read chunk, not proportional to the length of r. This trades away
+// Seek-ing backward: it's meant for a single disassembly pass over an
+// io.Reader (e.g. stdin), not for the simulator's jumps, which need
+// NewDecoderBytes's whole-buffer random access instead.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
 }
 
-func (d *Decoder) Next() (Opcode, []byte, error) {
-	if !d.HasNext() {
-		return 0, nil, io.EOF
-	}
-
-	// We pass in the next two bytes, to try to analyze the opcode.
-	// Creates an slice that contains the bytes of the instruction
-	inst := d.Data[d.pos : d.pos+2] // end of slice range is exlusive
-	opcode, bytesToRead, error := d.analyzeOpCode(inst)
-
-	if error != nil {
-		return 0, nil, error
-	}
-
-	fullInstruction := d.Data[d.pos : d.pos+bytesToRead]
-	d.pos += bytesToRead
-	return opcode, fullInstruction, nil
+// NewDecoderBytes returns a Decoder over a program already fully in
+// memory, for callers that don't need to stream it.
+func NewDecoderBytes(data []byte) *Decoder {
+	return &Decoder{buf: data, readErr: io.EOF}
 }
 
-type Opcode byte
-
-const (
-	// MOV destination, sourcce
-	// Register/memory to/from register
-	MovRegisterMemoryToFromRegister Opcode = 0b0010_0010
-
-	// Immediate to register/memory
-	MovImmediateToRegisterMemory Opcode = 0b0110_0011
-
-	// Immediate to register.
-	MovImmediateToRegister Opcode = 0b1011
-
-	// Memory to accumulator
-	MovMemoryToAccumulator Opcode = 0b0101_0000
-
-	// Accumulator to memory
-	MovAccumulatorToMemory Opcode = 0b0101_0001
-)
-
-// Returns the opcode name and the lenght of bytes to read for this opcode.
-func (d *Decoder) analyzeOpCode(instruction []byte) (Opcode, int, error) {
-	firstByte := instruction[0]
-	// op code is usually encoded in the first 6 bits of the first byte.
-	if firstByte>>2 == byte(MovRegisterMemoryToFromRegister) {
-		// Register mode/Memory mode with displacement length
-		modField := instruction[1] >> 6
-		var bytesToRead int = 0
-
-		switch modField {
-		case 0b00:
-			// Memory mode, no displacement follows.
-			// Except when R/M field = 110, then, 16-bit displacement follwos.
-			rmField := instruction[1] & 0b0000_0111
-			if rmField == 0b110 {
-				bytesToRead = 4
-			} else {
-				bytesToRead = 2
-			}
-		case 0b01:
-			// Memory mode, 8 bit displacement follows
-			bytesToRead = 3 // An additional byte
-		case 0b10:
-			// Memory mode, 16 bit displacement follows
-			bytesToRead = 4 // Two additional bytes.
-		case 0b11:
-			// Register mode (no displacement)
-			bytesToRead = 2
-		}
-
-		return MovRegisterMemoryToFromRegister, bytesToRead, nil
-	} else if firstByte>>1 == byte(MovMemoryToAccumulator) {
-		var bytesToRead int = 2
-		wField := firstByte&0b1 == 1
-		if wField {
-			// additional data byte
-			bytesToRead += 1
-		}
-		return MovMemoryToAccumulator, bytesToRead, nil
-	} else if firstByte>>1 == byte(MovAccumulatorToMemory) {
-		var bytesToRead int = 2
-		wField := firstByte&0b1 == 1
-		if wField {
-			// additional data byte
-			bytesToRead += 1
-		}
-		return MovAccumulatorToMemory, bytesToRead, nil
-	} else if firstByte>>1 == byte(MovImmediateToRegisterMemory) {
-		// Two bytes for op encoding, and a data byte
-		var bytesToRead int = 3
-		wField := firstByte&0b1 == 1
-		if wField {
-			// additional data byte
-			bytesToRead += 1
-		}
-
-		// Register mode/Memory mode with displacement length
-		modField := instruction[1] >> 6
-		switch modField {
-		case 0b01:
-			// Memory mode, 8 bit displacement follows
-			bytesToRead += 1 // An additional byte
-		case 0b10:
-			// Memory mode, 16 bit displacement follows
-			bytesToRead += 2 // Two additional bytes.
-		}
-		return MovImmediateToRegisterMemory, bytesToRead, nil
-	} else if firstByte>>4 == byte(MovImmediateToRegister) {
-		var bytesToRead int = 2
-		var isWord bool = (firstByte>>3)&0b00001 == 1
-		if isWord {
-			bytesToRead = 3
-		}
-
-		return MovImmediateToRegister, bytesToRead, nil
-	}
-
-	return 0, 0, errors.New("cannot identify instruction")
+// SetSyntax changes which assembly flavor AsmString/AsmStringAt render in.
+func (d *Decoder) SetSyntax(syntax Syntax) {
+	d.syntax = syntax
 }
 
-func effectiveAddressCalculation(rmField byte) string {
-	switch rmField {
-	case 0b00:
-		return "bx + si"
-	case 0b001:
-		return "bx + di"
-	case 0b010:
-		return "bp + si"
-	case 0b011:
-		return "bp + di"
-	case 0b100:
-		return "si"
-	case 0b101:
-		return "di"
-	case 0b110:
-		// 16 bits direct address when mod = 00
-		return "bp"
-	case 0b111:
-		return "bx"
-	}
-
-	return ""
+// Pos reports the decoder's current byte offset into the stream.
+func (d *Decoder) Pos() int {
+	return d.pos
 }
 
-// Register/memory to/from register
-// [1 0 0 0 1 0 d w]
-// [mod(2 bits) reg(3 bits) rm(3 bits)]
-// [Displacement Low (8 bits)]
-// [Displacement Hight (8 bits)]
-func decodeMovRegisterMemoryToFromRegister(instruction []byte) string {
-	var builder strings.Builder // Zero value is ready to use
-
-	// The bit 8 of first byte determine the w field:
-	// when 0, instruction operates on byte data
-	// when 1, instructions operate on word data
-	w := instruction[0]&0b1 == 1
-
-	// when 0, instruction source is specified in ref field.
-	// when 1, instruction destination is specified in reg field
-	d := (instruction[0]>>1)&0b1 == 1
-
-	modField := (instruction[1] >> 6) & 0b11
-
-	// 2. Decode the source registry (when bit 7 of first byte is 0, reg is the source)
-	// Destination is in in second byte.
-	regField := (instruction[1] >> 3) & 0b0000_0111
-
-	// 3. Decode the destination registry.
-	rmField := instruction[1] & 0b0000_0111
-
-	builder.WriteString("mov ")
-
-	switch modField {
-	case 0b00:
-		// Memory only, no displacement follows
-		// except when rmField = 110
-
-		// Use reg field as the destination.
-		if d {
-			builder.WriteString(fmt.Sprintf("%v, ", byteToRegisterString(w, regField)))
-		}
-
-		if rmField == 0b110 {
-			displacement := int16(instruction[2]) | int16(instruction[3])<<8
-			builder.WriteString(
-				fmt.Sprintf("[%v]", displacement),
-			)
-		} else {
-			builder.WriteString(
-				fmt.Sprintf("[%v]", effectiveAddressCalculation(rmField)),
-			)
-		}
-
-		// use reg field as the source
-		if !d {
-			builder.WriteString(fmt.Sprintf(", %v", byteToRegisterString(w, regField)))
-		}
-	case 0b01:
-		// Memory mode, 8-bit displacement follows.
-
-		// Use reg field as the destination.
-		if d {
-			builder.WriteString(fmt.Sprintf("%v, ", byteToRegisterString(w, regField)))
-		}
-
-		displacement := int8(instruction[2])
-		builder.WriteString(
-			fmt.Sprintf("[%v + %v]", effectiveAddressCalculation(rmField), displacement),
-		)
-
-		// use reg field as the source
-		if !d {
-			builder.WriteString(fmt.Sprintf(", %v", byteToRegisterString(w, regField)))
-		}
-	case 0b10:
-		// Memory mode, 16-bit displacement follows
-		// Use reg field as the destination.
-		if d {
-			builder.WriteString(fmt.Sprintf("%v, ", byteToRegisterString(w, regField)))
-		}
-
-		displacement := int16(instruction[2]) | int16(instruction[3])<<8
-		builder.WriteString(
-			fmt.Sprintf("[%v + %v]", effectiveAddressCalculation(rmField), displacement),
-		)
-
-		// use reg field as the source
-		if !d {
-			builder.WriteString(fmt.Sprintf(", %v", byteToRegisterString(w, regField)))
-		}
-	case 0b11:
-		// Register mode, no displacement
-		builder.WriteString(
-			fmt.Sprintf(
-				"%v, %v",
-				byteToRegisterString(w, rmField),
-				byteToRegisterString(w, regField),
-			),
-		)
+// Seek repositions the decoder to byte offset pos, so a caller that needs
+// to redirect the instruction stream (a simulator resolving a jump) can
+// do so without rebuilding the Decoder. On a streaming (NewDecoder)
+// source it returns an error instead of seeking if pos names a byte peek
+// has already discarded - those bytes are gone for good, unlike on a
+// NewDecoderBytes source, where Seek never fails.
+func (d *Decoder) Seek(pos int) error {
+	if d.r != nil && pos < d.bufStart {
+		return fmt.Errorf("decoder: cannot seek to offset %d on a streaming source, bytes before offset %d are already discarded", pos, d.bufStart)
 	}
-
-	return builder.String()
+	d.pos = pos
+	return nil
 }
 
-// Register/memory to/from register
-// [1 1 0 0 0 1 1 w]
-// [mod(2 bits) 0 0 0 rm(3 bits)]
-// [Displacement Low (8 bits)]
-// [Displacement Hight (8 bits)]
-// [Data]
-// [Data(if w = 1)]
-func decodeMovImmediateToRegisterMemory(instruction []byte) string {
-	var builder strings.Builder // Zero value is ready to use
-
-	// The bit 8 of first byte determine the w field:
-	// when 0, instruction operates on byte data
-	// when 1, instructions operate on word data
-	w := instruction[0]&0b1 == 1
-
-	modField := (instruction[1] >> 6) & 0b11
+// Instruction is one decoded instruction together with where it came from
+// in the stream, returned by Decode.
+type Instruction struct {
+	PC    uint64
+	Bytes []byte
+	Size  int
+	Inst
+}
 
-	// 2. Decode the source registry (when bit 7 of first byte is 0, reg is the source)
-	// Destination is in in second byte.
-	// regField := (instruction[1] >> 3) & 0b0000_0111
+// Decode reads and decodes the next instruction, returning io.EOF once
+// the stream is exhausted. An instruction cut off by the end of the
+// stream reports an error naming the offset it started at rather than
+// io.EOF, so callers can tell a clean end from a truncated one. Decode
+// needs at least two bytes left to decode anything: a single byte left at
+// the end of the stream is reported as truncated even when it is a
+// complete one-byte instruction.
+func (d *Decoder) Decode() (Instruction, error) {
+	peek, err := d.peek(maxInstructionLength)
+	if err != nil {
+		return Instruction{}, err
+	}
+	if len(peek) == 0 {
+		return Instruction{}, io.EOF
+	}
+	if len(peek) < 2 {
+		return Instruction{}, fmt.Errorf("decoder: truncated instruction at offset %d", d.pos)
+	}
 
-	// 3. Decode the destination registry.
-	rmField := instruction[1] & 0b0000_0111
+	opcode, length, err := analyzeOpCode(peek)
+	if err != nil {
+		return Instruction{}, err
+	}
+	if length > len(peek) {
+		return Instruction{}, fmt.Errorf("decoder: truncated instruction at offset %d: need %d bytes, have %d", d.pos, length, len(peek))
+	}
 
-	builder.WriteString("mov ")
+	pc := d.pos
+	raw := peek[:length]
+	d.pos += length
 
-	switch modField {
-	case 0b00:
-		// Memory only, no displacement follows
-		// except when rmField = 110
-		if rmField == 0b110 {
-			builder.WriteString("direct address 2")
-		} else {
-			builder.WriteString(
-				fmt.Sprintf("[%v]", effectiveAddressCalculation(rmField)),
-			)
-		}
+	return Instruction{PC: uint64(pc), Bytes: raw, Size: length, Inst: opcode.format.decode(raw)}, nil
+}
 
-		if w {
-			data := uint16(instruction[2]) | uint16(instruction[3])<<8
-			builder.WriteString(
-				fmt.Sprintf(", word %v", data),
-			)
-		} else {
-			data := instruction[2]
-			builder.WriteString(
-				fmt.Sprintf(", byte %v", data),
-			)
+// peek returns up to n bytes starting at the decoder's current position,
+// reading from the underlying reader as needed; it returns fewer than n
+// bytes only once the reader is exhausted, and a non-nil error only on a
+// genuine read failure (not plain end-of-stream).
+func (d *Decoder) peek(n int) ([]byte, error) {
+	// On a streaming (NewDecoder) source, bytes before pos are never
+	// addressed again - Decode only ever moves forward - so drop them
+	// instead of growing buf to the whole stream. NewDecoderBytes sources
+	// have r == nil and skip this, since the simulator seeks backward on
+	// them for jumps.
+	if d.r != nil {
+		if drop := d.pos - d.bufStart; drop > 0 {
+			d.buf = d.buf[drop:]
+			d.bufStart = d.pos
 		}
-	case 0b01:
-		// Memory mode, 8-bit displacement follows.
-		displacement := int8(instruction[2])
-		builder.WriteString(
-			fmt.Sprintf("[%v + %v]", effectiveAddressCalculation(rmField), displacement),
-		)
+	}
+	local := d.pos - d.bufStart
 
-		if w {
-			data := uint16(instruction[3]) | uint16(instruction[4])<<8
-			builder.WriteString(
-				fmt.Sprintf(", word %v", data),
-			)
-		} else {
-			data := instruction[3]
-			builder.WriteString(
-				fmt.Sprintf(", byte %v", data),
-			)
+	for len(d.buf)-local < n && d.readErr == nil {
+		chunk := make([]byte, 4096)
+		read, err := d.r.Read(chunk)
+		if read > 0 {
+			d.buf = append(d.buf, chunk[:read]...)
 		}
-	case 0b10:
-		// Memory mode, 16-bit displacement follows
-		displacement := uint16(instruction[2]) | uint16(instruction[3])<<8
-		builder.WriteString(
-			fmt.Sprintf("[%v + %v]", effectiveAddressCalculation(rmField), displacement),
-		)
-
-		if w {
-			data := uint16(instruction[4]) | uint16(instruction[5])<<8
-			builder.WriteString(
-				fmt.Sprintf(", word %v", data),
-			)
-		} else {
-			data := instruction[4]
-			builder.WriteString(
-				fmt.Sprintf(", byte %v", data),
-			)
+		if err != nil {
+			d.readErr = err
 		}
 	}
-
-	return builder.String()
-}
-
-// Immediate to register.
-// [1 0 1 1 w reg(3 bits)]
-// [data(8 bits)]
-// [data(8 bits - if w = 1)]
-func decodeMovImmediateToRegister(instruction []byte) string {
-	var builder strings.Builder // Zero value is ready to use
-
-	// The bit 8 of first byte determine the isWord field:
-	// when 0, instruction operates on byte data
-	// when 1, instructions operate on word data
-	isWord := (instruction[0]>>3)&0b1 == 1
-	// 2. Decode the source registry (when bit 7 of first byte is 0, reg is the source)
-	// Destination is in in second byte.
-	regField := instruction[0] & 0b0000_0111
-
-	var data uint16 = uint16(instruction[1])
-	// 16-bit immediate-to-register
-	if isWord {
-		data = data | (uint16(instruction[2]) << 8)
+	if d.readErr != nil && d.readErr != io.EOF {
+		return nil, d.readErr
 	}
 
-	builder.WriteString("mov ")
-	builder.WriteString(
-		fmt.Sprintf(
-			"%v, %v",
-			byteToRegisterString(isWord, regField),
-			data,
-		),
-	)
-
-	return builder.String()
+	end := local + n
+	if end > len(d.buf) {
+		end = len(d.buf)
+	}
+	return d.buf[local:end], nil
 }
 
-// [1 0 1 0 0 0 0 w]
-// [address low]
-// [address high]
-func decodeMemoryToAccumulator(instruction []byte) string {
-	var builder strings.Builder // Zero value is ready to use
-
-	// The bit 8 of first byte determine the isWord field:
-	// when 0, instruction operates on byte data
-	// when 1, instructions operate on word data
-	isWord := instruction[0]&0b1 == 1
+// opcode identifies which instFormat matched a decoded instruction; it
+// only lives for the duration of a single Decode call.
+type opcode struct {
+	format *instFormat
+}
 
-	builder.WriteString("mov ax, ")
-	if isWord {
-		address := uint16(instruction[1]) | uint16(instruction[2])<<8
-		builder.WriteString(
-			fmt.Sprintf("[%v]", address),
-		)
-	} else {
-		address := instruction[1]
-		builder.WriteString(
-			fmt.Sprintf("[%v]", address),
-		)
+// analyzeOpCode finds the instFormat matching the next instruction and
+// the number of bytes it occupies, given at least its first two bytes.
+// Matching is a linear scan over instFormats: the days of a cascading "if
+// firstByte>>N == ..." chain per opcode are over, adding an instruction
+// is now a table entry (see instformat.go).
+func analyzeOpCode(peek []byte) (opcode, int, error) {
+	firstByte := peek[0]
+	for i := range instFormats {
+		f := &instFormats[i]
+		if firstByte&f.mask == f.value {
+			return opcode{format: f}, f.length(peek), nil
+		}
 	}
 
-	return builder.String()
+	return opcode{}, 0, errors.New("cannot identify instruction")
 }
 
-// [1 0 1 0 0 0 1 w]
-// [address low]
-// [address high]
-func decodeAccumulatorToMemory(instruction []byte) string {
-	var builder strings.Builder // Zero value is ready to use
-
-	// The bit 8 of first byte determine the isWord field:
-	// when 0, instruction operates on byte data
-	// when 1, instructions operate on word data
-	isWord := instruction[0]&0b1 == 1
-
-	builder.WriteString("mov ")
-	if isWord {
-		address := uint16(instruction[1]) | uint16(instruction[2])<<8
-		builder.WriteString(
-			fmt.Sprintf("[%v]", address),
-		)
-	} else {
-		address := instruction[1]
-		builder.WriteString(
-			fmt.Sprintf("[%v]", address),
-		)
-	}
-	builder.WriteString(", ax")
-
-	return builder.String()
+// AsmString renders a decoded instruction as assembly text in the
+// Decoder's current syntax (SetSyntax; Intel by default).
+func (d *Decoder) AsmString(i Instruction) string {
+	return Format(i.Inst, d.syntax)
 }
 
-func (d *Decoder) AsmString(opcode Opcode, instruction []byte) string {
-	// op code is usually encoded in the first 6 bits of the first byte.
-	switch opcode {
-	case MovRegisterMemoryToFromRegister:
-		return decodeMovRegisterMemoryToFromRegister(instruction)
-	case MovImmediateToRegisterMemory:
-		return decodeMovImmediateToRegisterMemory(instruction)
-	case MovMemoryToAccumulator:
-		return decodeMemoryToAccumulator(instruction)
-	case MovAccumulatorToMemory:
-		return decodeAccumulatorToMemory(instruction)
-	case MovImmediateToRegister:
-		return decodeMovImmediateToRegister(instruction)
+// AsmStringAt renders a decoded instruction the same way AsmString does,
+// except a jump or call's relative displacement is resolved to an
+// absolute target and symbolized rather than shown in NASM's "$+N" form.
+// symname resolves an absolute address to a name the way
+// golang.org/x/arch's GoSyntax formatter does; pass nil to fall back to
+// auto-generated "label_XXXX" names for targets inside this decoder's
+// buffer (see CollectLabels for a two-pass caller that wants those names
+// fixed up front).
+func (d *Decoder) AsmStringAt(i Instruction, symname SymName) string {
+	inst := i.Inst
+	for idx := range inst.Args {
+		if inst.Args[idx].Kind == ArgRel {
+			inst.Args[idx].Label = d.resolveLabel(int(i.PC), inst.Args[idx].Rel, symname)
+		}
 	}
-
-	return ""
+	return Format(inst, d.syntax)
 }
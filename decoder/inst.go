@@ -0,0 +1,61 @@
+package decoder
+
+// Inst is a decoded instruction in a form that hasn't committed to any
+// assembly flavor yet: a mnemonic plus its operands. The per-opcode
+// decode functions in operands.go build one of these; formatting it as
+// text is FormatIntel/FormatATT's job (see syntax.go), the same split
+// golang.org/x/arch's x86asm draws between decoding and its
+// GNUSyntax/GoSyntax/IntelSyntax formatters.
+type Inst struct {
+	Op   string
+	Args []Arg
+	// Wide reports whether the instruction operates on a word (true) or
+	// a byte (false); formatters use it to pick a size keyword/suffix
+	// when no operand already implies it.
+	Wide bool
+}
+
+// ArgKind identifies which field of Arg is meaningful.
+type ArgKind int
+
+const (
+	ArgReg ArgKind = iota
+	ArgImm
+	ArgMem
+	ArgRel
+	ArgFar
+)
+
+// Arg is one operand of a decoded instruction.
+type Arg struct {
+	Kind ArgKind
+
+	Reg string // ArgReg: register name, e.g. "ax", "al".
+
+	Imm int64 // ArgImm: an immediate value or a ret/retf pop count.
+
+	Mem MemArg // ArgMem: a bracketed memory operand.
+
+	Rel int // ArgRel: a short/near jump's displacement, relative to the address of the jumping instruction ("$" in nasm).
+
+	// Label is ArgRel's resolved target text (a label or symbol name,
+	// optionally "+offset", or a bare hex address), set by
+	// Decoder.AsmStringAt once a program counter is available to resolve
+	// against. It is empty for Args built by AsmString, which has no PC
+	// to resolve relative to and falls back to formatRelativeJump's
+	// NASM-style "$+N".
+	Label string
+
+	FarSegment uint16 // ArgFar: the segment half of a direct far call/jmp pointer.
+	FarOffset  uint16 // ArgFar: the offset half.
+}
+
+// MemArg is a memory operand's address expression: up to two base/index
+// registers (the 8086 only ever combines bx/bp with si/di) plus an
+// optional displacement. A direct address (mod=00, rm=110) has no
+// registers and HasDisp always true.
+type MemArg struct {
+	Regs    []string
+	Disp    int
+	HasDisp bool
+}
@@ -0,0 +1,125 @@
+package decoder
+
+//go:generate go run ../cmd/x86map -csv ../cmd/x86map/x86.csv -out tables.go -registers-csv ../cmd/x86map/registers.csv -ea-csv ../cmd/x86map/ea.csv -regs-out regtables.go
+
+// instFormat describes one byte-level encoding pattern for an 8086
+// instruction, in the spirit of golang.org/x/arch's table-driven decoders
+// (e.g. armasm's instFormat): an instruction matches when its first byte,
+// masked, equals value. length reports how many bytes the full
+// instruction occupies once the rest of its encoding (mod/reg/rm,
+// displacement, immediate) is accounted for, and decode builds its
+// structured Inst; rendering that as assembly text is Format's job (see
+// syntax.go).
+//
+// instFormats itself (see tables.go) is generated by cmd/x86map from
+// x86.csv rather than hand-maintained: each CSV row names one of the
+// constructors below by its "shape" and supplies the opcode's bit pattern
+// (and mnemonic, where the opcode byte alone determines it). cmd/x86map
+// turns the pattern into the mask/value pair below, so the constructors
+// themselves no longer hardcode which bits of the opcode byte are fixed.
+// Adding an instruction is a new CSV row, not a new Go branch.
+type instFormat struct {
+	mask  byte
+	value byte
+
+	length func(peek []byte) int
+	decode func(instruction []byte) Inst
+
+	// name is the mnemonic when the opcode byte alone determines it.
+	// Entries whose mnemonic instead depends on the reg field of the
+	// mod/reg/rm byte (the "group" opcodes) leave this blank and resolve
+	// their name as part of decode.
+	name string
+}
+
+func fixedLength(n int) func([]byte) int {
+	return func([]byte) int { return n }
+}
+
+// The constructors below are what cmd/x86map's generated tables.go calls,
+// one per CSV row "shape"; each wires a mask/value pair (and mnemonic,
+// for shapes where the opcode byte alone doesn't select it) to the
+// length/decode functions in operands.go that already know how to read
+// that shape.
+
+func noOpEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: fixedLength(1), decode: decodeNoOperand(name)}
+}
+
+func retImmEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: fixedLength(3), decode: func(i []byte) Inst { return decodeRetImm(name, i) }}
+}
+
+func directJumpEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: fixedLength(3), decode: func(i []byte) Inst { return decodeDirectJump(name, i) }}
+}
+
+func relJumpEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: fixedLength(2), decode: func(i []byte) Inst { return decodeRelJump(name, i) }}
+}
+
+func farDirectEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: fixedLength(5), decode: func(i []byte) Inst { return decodeFarDirect(name, i) }}
+}
+
+// group1Entry covers the 0x80/0x81/0x83 immediate-arithmetic opcodes,
+// whose mnemonic is selected by the mod/reg/rm byte's reg field rather
+// than the opcode byte, so there's no mnemonic column to read.
+func group1Entry(mask, value byte) instFormat {
+	return instFormat{mask: mask, value: value, length: group1Length, decode: decodeGroup1}
+}
+
+// groupFEEntry/groupFFEntry cover the 0xFE/0xFF "group 2" opcodes
+// (inc/dec/push/call/jmp through a mod/reg/rm byte), same reg-field
+// dispatch as group 1.
+func groupFEEntry(mask, value byte) instFormat {
+	return instFormat{mask: mask, value: value, length: regMemLength, decode: func(i []byte) Inst { return decodeGroupMem(groupFENames, false, i) }}
+}
+
+func groupFFEntry(mask, value byte) instFormat {
+	return instFormat{mask: mask, value: value, length: regMemLength, decode: func(i []byte) Inst { return decodeGroupMem(groupFFNames, true, i) }}
+}
+
+func popRegMemEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: regMemLength, decode: decodePopRegMem}
+}
+
+func accMemLoadEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: accMemLength, decode: decodeMemToAcc}
+}
+
+func accMemStoreEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: accMemLength, decode: decodeAccToMem}
+}
+
+func immToRegMemEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: immToRegMemLength, decode: func(i []byte) Inst { return decodeImmToRegMem(name, i) }}
+}
+
+func xchgRegMemEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: regMemLength, decode: decodeXchgRegMem}
+}
+
+func immToAccEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: immToAccLength, decode: func(i []byte) Inst { return decodeImmToAcc(name, i) }}
+}
+
+func modRegRMEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: regMemLength, decode: func(i []byte) Inst { return decodeModRegRMFamily(name, i) }}
+}
+
+func regInOpEntry(mask, value byte, name string) instFormat {
+	return instFormat{mask: mask, value: value, name: name, length: fixedLength(1), decode: func(i []byte) Inst { return decodeRegInOp(name, i) }}
+}
+
+// xchgAxRegEntry's mnemonic is always "xchg" (0x90 itself, xchg ax,ax, is
+// claimed by the noOpEntry "nop" row ahead of it in the table), so there's
+// no mnemonic column for it either.
+func xchgAxRegEntry(mask, value byte) instFormat {
+	return instFormat{mask: mask, value: value, name: "xchg", length: fixedLength(1), decode: decodeXchgAxReg}
+}
+
+// immToRegEntry's mnemonic is always mov's "immediate to register" form.
+func immToRegEntry(mask, value byte) instFormat {
+	return instFormat{mask: mask, value: value, name: "mov", length: immToRegLength, decode: decodeImmToReg}
+}
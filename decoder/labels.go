@@ -0,0 +1,110 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SymName resolves an absolute address to a symbol name and the address
+// that name is anchored at, mirroring the symname callback
+// golang.org/x/arch's x86asm GoSyntax formatter takes. Returning ("", 0)
+// means addr has no known symbol.
+type SymName func(addr uint64) (name string, base uint64)
+
+// LabelTable records the absolute jump/call targets found by a
+// CollectLabels pass and the auto-generated label name assigned to each,
+// so a two-pass caller can know ahead of time which addresses need a
+// "label_XXXX:" line and use consistent names when it gets there.
+type LabelTable struct {
+	names map[uint64]string
+}
+
+// CollectLabels scans the instruction stream from the decoder's current
+// position to its end, decoding every instruction without consuming the
+// decoder's position permanently, and records the absolute target of
+// every jump/call whose displacement lands inside the stream. Run this
+// before a second pass that calls AsmStringAt with the returned table's
+// Resolve method, so forward jumps get the same label a backward jump to
+// the same address would.
+//
+// It requires random access to the whole stream - scanning to EOF and
+// then rewinding - so it only works on a NewDecoderBytes source; called
+// on a streaming (NewDecoder) source, where bytes behind the current
+// position are already gone, it returns an error instead of rewinding
+// into data that no longer exists.
+func (d *Decoder) CollectLabels() (*LabelTable, error) {
+	if d.r != nil {
+		return nil, errors.New("decoder: CollectLabels requires a NewDecoderBytes source, not a streaming NewDecoder one")
+	}
+
+	saved := d.pos
+	defer func() { d.pos = saved }()
+
+	var targets []int
+	for {
+		instr, err := d.Decode()
+		if err != nil {
+			break
+		}
+		for _, a := range instr.Args {
+			if a.Kind == ArgRel {
+				targets = append(targets, int(instr.PC)+a.Rel)
+			}
+		}
+	}
+
+	total := len(d.buf)
+	table := &LabelTable{names: map[uint64]string{}}
+	for _, t := range targets {
+		if t < 0 || t >= total {
+			continue
+		}
+		addr := uint64(t)
+		if _, ok := table.names[addr]; !ok {
+			table.names[addr] = fmt.Sprintf("label_0x%04X", addr)
+		}
+	}
+	return table, nil
+}
+
+// Resolve implements SymName against the labels CollectLabels found:
+// every jump target it recorded resolves to its own auto-generated label.
+func (t *LabelTable) Resolve(addr uint64) (string, uint64) {
+	if name, ok := t.names[addr]; ok {
+		return name, addr
+	}
+	return "", 0
+}
+
+// LabelAt reports the label name a two-pass caller should emit as a
+// "name:" line right before the instruction at addr, if any jump target
+// from the CollectLabels pass landed there.
+func (t *LabelTable) LabelAt(addr uint64) (string, bool) {
+	name, ok := t.names[addr]
+	return name, ok
+}
+
+// resolveLabel turns a jump/call's relative displacement into the text
+// AsmStringAt should render: a name from symname when one resolves, an
+// auto-generated "label_XXXX" when symname is nil and the target falls
+// inside this decoder's buffered stream, or a bare hex address otherwise
+// (including when a non-nil symname does not resolve the target).
+func (d *Decoder) resolveLabel(pc int, rel int, symname SymName) string {
+	target := pc + rel
+	addr := uint64(target)
+
+	if symname != nil {
+		if name, base := symname(addr); name != "" {
+			if addr == base {
+				return name
+			}
+			return fmt.Sprintf("%s+0x%x", name, addr-base)
+		}
+		return fmt.Sprintf("0x%X", addr)
+	}
+
+	if target >= 0 && target < len(d.buf) {
+		return fmt.Sprintf("label_0x%04X", addr)
+	}
+	return fmt.Sprintf("0x%X", addr)
+}
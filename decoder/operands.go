@@ -0,0 +1,340 @@
+package decoder
+
+// modRMSize returns how many displacement bytes follow a mod/reg/rm byte,
+// from the mod and r/m fields alone (no need for the rest of the
+// instruction to be in hand yet). This is what lets analyzeOpCode figure
+// out an instruction's total length from just the opcode and mod/reg/rm
+// bytes, before the displacement/immediate bytes have even been sliced out.
+func modRMSize(modByte byte) int {
+	modField := modByte >> 6
+	rmField := modByte & 0b111
+
+	switch modField {
+	case 0b00:
+		if rmField == 0b110 {
+			// Direct address: 16-bit displacement.
+			return 2
+		}
+		return 0
+	case 0b01:
+		return 1
+	case 0b10:
+		return 2
+	}
+
+	return 0 // mod == 0b11, register mode, no displacement.
+}
+
+// memBaseRegs lists the base/index register pair effective address mode
+// rmField selects (decoder/regtables.go's effectiveAddressRegs, generated
+// from cmd/x86map/ea.csv); mod=11 (register mode) and rm=110 with mod=00
+// (direct address) are handled by the caller instead.
+func memBaseRegs(rmField byte) []string {
+	if int(rmField) >= len(effectiveAddressRegs) {
+		return nil
+	}
+	return effectiveAddressRegs[rmField]
+}
+
+// decodeModRM splits a mod/reg/rm byte at instruction[offset] and returns
+// the reg field, an Arg for the r/m operand (a register in register mode,
+// a memory operand otherwise), and how many displacement bytes were
+// consumed after it.
+func decodeModRM(instruction []byte, offset int, w bool) (regField byte, rm Arg, dispBytes int) {
+	modByte := instruction[offset]
+	modField := modByte >> 6
+	regField = (modByte >> 3) & 0b111
+	rmField := modByte & 0b111
+
+	if modField == 0b11 {
+		return regField, Arg{Kind: ArgReg, Reg: byteToRegisterString(w, rmField)}, 0
+	}
+
+	if modField == 0b00 && rmField == 0b110 {
+		displacement := int(int16(instruction[offset+1]) | int16(instruction[offset+2])<<8)
+		return regField, Arg{Kind: ArgMem, Mem: MemArg{Disp: displacement, HasDisp: true}}, 2
+	}
+
+	dispBytes = modRMSize(modByte)
+	switch dispBytes {
+	case 0:
+		return regField, Arg{Kind: ArgMem, Mem: MemArg{Regs: memBaseRegs(rmField)}}, 0
+	case 1:
+		displacement := int(int8(instruction[offset+1]))
+		return regField, Arg{Kind: ArgMem, Mem: MemArg{Regs: memBaseRegs(rmField), Disp: displacement, HasDisp: true}}, 1
+	default:
+		displacement := int(int16(instruction[offset+1]) | int16(instruction[offset+2])<<8)
+		return regField, Arg{Kind: ArgMem, Mem: MemArg{Regs: memBaseRegs(rmField), Disp: displacement, HasDisp: true}}, 2
+	}
+}
+
+// decodeModRegRMFamily decodes the "register/memory to/from register"
+// shape shared by mov and all the two-operand arithmetic opcodes
+// (add/or/adc/sbb/and/sub/xor/cmp): [op(6) d w][mod reg rm][disp...].
+func decodeModRegRMFamily(name string, instruction []byte) Inst {
+	w := instruction[0]&0b1 == 1
+	d := (instruction[0]>>1)&0b1 == 1
+
+	regField, rm, _ := decodeModRM(instruction, 1, w)
+	reg := Arg{Kind: ArgReg, Reg: byteToRegisterString(w, regField)}
+
+	dst, src := rm, reg
+	if d {
+		dst, src = reg, rm
+	}
+	return Inst{Op: name, Args: []Arg{dst, src}, Wide: w}
+}
+
+// group1Names are the mnemonics selected by the reg field of the mod/reg/rm
+// byte in the 0x80/0x81/0x83 "immediate to register/memory" opcodes.
+var group1Names = [8]string{"add", "or", "adc", "sbb", "and", "sub", "xor", "cmp"}
+
+// decodeGroup1 decodes the group 1 immediate arithmetic opcodes: 0x80
+// (imm8 -> r/m8), 0x81 (imm16 -> r/m16) and 0x83 (sign-extended imm8 ->
+// r/m16). The mnemonic itself lives in the reg field rather than the
+// opcode byte.
+func decodeGroup1(instruction []byte) Inst {
+	w := instruction[0]&0b1 == 1
+	signExtend := instruction[0] == 0x83
+
+	regField, rm, dispBytes := decodeModRM(instruction, 1, w)
+	name := group1Names[regField]
+
+	immOffset := 2 + dispBytes
+	var data int64
+	switch {
+	case signExtend:
+		data = int64(int8(instruction[immOffset]))
+	case w:
+		data = int64(uint16(instruction[immOffset]) | uint16(instruction[immOffset+1])<<8)
+	default:
+		data = int64(instruction[immOffset])
+	}
+
+	return Inst{Op: name, Args: []Arg{rm, {Kind: ArgImm, Imm: data}}, Wide: w}
+}
+
+func group1Length(instr []byte) int {
+	dispBytes := modRMSize(instr[1])
+	if instr[0] == 0x81 {
+		return 2 + dispBytes + 2
+	}
+	return 2 + dispBytes + 1 // 0x80 and 0x83 both carry a single immediate byte.
+}
+
+// decodeImmToRegMem decodes "immediate to register/memory" opcodes whose
+// reg field is unused padding rather than a sub-opcode selector (mov
+// 0xC6/0xC7). The group 1 arithmetic opcodes use decodeGroup1 instead.
+func decodeImmToRegMem(name string, instruction []byte) Inst {
+	w := instruction[0]&0b1 == 1
+	_, rm, dispBytes := decodeModRM(instruction, 1, w)
+
+	immOffset := 2 + dispBytes
+	var data int64
+	if w {
+		data = int64(uint16(instruction[immOffset]) | uint16(instruction[immOffset+1])<<8)
+	} else {
+		data = int64(instruction[immOffset])
+	}
+
+	return Inst{Op: name, Args: []Arg{rm, {Kind: ArgImm, Imm: data}}, Wide: w}
+}
+
+func immToRegMemLength(instr []byte) int {
+	dispBytes := modRMSize(instr[1])
+	if instr[0]&0b1 == 1 {
+		return 2 + dispBytes + 2
+	}
+	return 2 + dispBytes + 1
+}
+
+// decodeImmToAcc decodes arithmetic-with-accumulator immediates, e.g.
+// "0000_010w data" for add and "0011_110w data" for cmp: the accumulator
+// (al/ax) is always one operand, the immediate that follows the opcode
+// byte is the other.
+func decodeImmToAcc(name string, instruction []byte) Inst {
+	w := instruction[0]&0b1 == 1
+	var data int64
+	if w {
+		data = int64(uint16(instruction[1]) | uint16(instruction[2])<<8)
+	} else {
+		data = int64(instruction[1])
+	}
+	acc := Arg{Kind: ArgReg, Reg: byteToRegisterString(w, 0)}
+	return Inst{Op: name, Args: []Arg{acc, {Kind: ArgImm, Imm: data}}, Wide: w}
+}
+
+func immToAccLength(instr []byte) int {
+	if instr[0]&0b1 == 1 {
+		return 3
+	}
+	return 2
+}
+
+// decodeImmToReg decodes mov's "immediate to register" opcodes
+// (0xB0-0xBF): [1 0 1 1 w reg][data][data if w].
+func decodeImmToReg(instruction []byte) Inst {
+	w := (instruction[0]>>3)&0b1 == 1
+	regField := instruction[0] & 0b111
+
+	data := uint16(instruction[1])
+	if w {
+		data |= uint16(instruction[2]) << 8
+	}
+	reg := Arg{Kind: ArgReg, Reg: byteToRegisterString(w, regField)}
+	return Inst{Op: "mov", Args: []Arg{reg, {Kind: ArgImm, Imm: int64(data)}}, Wide: w}
+}
+
+func immToRegLength(instr []byte) int {
+	if (instr[0]>>3)&0b1 == 1 {
+		return 3
+	}
+	return 2
+}
+
+// decodeMemToAcc / decodeAccToMem decode mov's direct-address accumulator
+// opcodes: 0xA0/0xA1 load ax/al from a direct address, 0xA2/0xA3 store it.
+func decodeMemToAcc(instruction []byte) Inst {
+	w := instruction[0]&0b1 == 1
+	acc := Arg{Kind: ArgReg, Reg: byteToRegisterString(w, 0)}
+	return Inst{Op: "mov", Args: []Arg{acc, addressArg(instruction, w)}, Wide: w}
+}
+
+func decodeAccToMem(instruction []byte) Inst {
+	w := instruction[0]&0b1 == 1
+	acc := Arg{Kind: ArgReg, Reg: byteToRegisterString(w, 0)}
+	return Inst{Op: "mov", Args: []Arg{addressArg(instruction, w), acc}, Wide: w}
+}
+
+func addressArg(instruction []byte, w bool) Arg {
+	if w {
+		address := int(uint16(instruction[1]) | uint16(instruction[2])<<8)
+		return Arg{Kind: ArgMem, Mem: MemArg{Disp: address, HasDisp: true}}
+	}
+	return Arg{Kind: ArgMem, Mem: MemArg{Disp: int(instruction[1]), HasDisp: true}}
+}
+
+func accMemLength(instr []byte) int {
+	if instr[0]&0b1 == 1 {
+		return 3
+	}
+	return 2
+}
+
+// decodeXchgRegMem decodes xchg's register/memory form (0x86/0x87): like
+// decodeModRegRMFamily, but xchg has no d bit since the operands commute.
+func decodeXchgRegMem(instruction []byte) Inst {
+	w := instruction[0]&0b1 == 1
+	regField, rm, _ := decodeModRM(instruction, 1, w)
+	reg := Arg{Kind: ArgReg, Reg: byteToRegisterString(w, regField)}
+	return Inst{Op: "xchg", Args: []Arg{reg, rm}, Wide: w}
+}
+
+func regMemLength(instr []byte) int {
+	return 2 + modRMSize(instr[1])
+}
+
+// decodeRegInOp decodes single-byte opcodes where the register operand is
+// packed into the low 3 bits of the opcode itself, word-sized and with no
+// mod/reg/rm byte at all (inc/dec/push/pop reg).
+func decodeRegInOp(name string, instruction []byte) Inst {
+	reg := instruction[0] & 0b111
+	return Inst{Op: name, Args: []Arg{{Kind: ArgReg, Reg: byteToRegisterString(true, reg)}}, Wide: true}
+}
+
+// decodeXchgAxReg decodes xchg ax, reg (0x91-0x97); 0x90 itself is
+// reserved for nop (xchg ax, ax) and matched earlier in instFormats.
+func decodeXchgAxReg(instruction []byte) Inst {
+	reg := instruction[0] & 0b111
+	ax := Arg{Kind: ArgReg, Reg: "ax"}
+	other := Arg{Kind: ArgReg, Reg: byteToRegisterString(true, reg)}
+	return Inst{Op: "xchg", Args: []Arg{ax, other}, Wide: true}
+}
+
+// groupFENames/groupFFNames are the mnemonics selected by the reg field of
+// the mod/reg/rm byte in the 0xFE/0xFF "group 2" opcodes.
+var groupFENames = [8]string{"inc", "dec", "", "", "", "", "", ""}
+var groupFFNames = [8]string{"inc", "dec", "call", "call", "jmp", "jmp", "push", ""}
+
+// decodeGroupMem decodes the FE/FF group: the mnemonic comes from the reg
+// field and the sole operand is the r/m operand (register or memory).
+func decodeGroupMem(names [8]string, w bool, instruction []byte) Inst {
+	regField, rm, _ := decodeModRM(instruction, 1, w)
+	return Inst{Op: names[regField], Args: []Arg{rm}, Wide: w}
+}
+
+// decodePopRegMem decodes 0x8F /0, pop's register/memory form.
+func decodePopRegMem(instruction []byte) Inst {
+	_, rm, _ := decodeModRM(instruction, 1, true)
+	return Inst{Op: "pop", Args: []Arg{rm}, Wide: true}
+}
+
+// decodeNoOperand returns instructions that take no operands at all
+// (hlt, nop, clc, stc, ret, retf).
+func decodeNoOperand(name string) func([]byte) Inst {
+	return func([]byte) Inst { return Inst{Op: name} }
+}
+
+// decodeRetImm decodes ret/retf's "pop imm16 bytes off the stack after
+// returning" form (0xC2/0xCA).
+func decodeRetImm(name string, instruction []byte) Inst {
+	imm := int64(uint16(instruction[1]) | uint16(instruction[2])<<8)
+	return Inst{Op: name, Args: []Arg{{Kind: ArgImm, Imm: imm}}}
+}
+
+// decodeRelJump decodes short (rel8) jump-like instructions: conditional
+// jumps, the loop family, jcxz, and the short form of jmp. The encoded
+// displacement is relative to the address of the instruction *after*
+// this one, matching how the 8086 computes it; Rel adds the 2-byte
+// instruction length so it is relative to the jump itself, which is what
+// AsmStringAt resolves to a label.
+func decodeRelJump(name string, instruction []byte) Inst {
+	displacement := int(int8(instruction[1]))
+	return Inst{Op: name, Args: []Arg{{Kind: ArgRel, Rel: displacement + 2}}}
+}
+
+// decodeDirectJump decodes near call/jmp's rel16 form (0xE8/0xE9).
+func decodeDirectJump(name string, instruction []byte) Inst {
+	displacement := int(int16(instruction[1]) | int16(instruction[2])<<8)
+	return Inst{Op: name, Args: []Arg{{Kind: ArgRel, Rel: displacement + 3}}}
+}
+
+// formatRelativeJump renders a jump target the way nasm does when no
+// label is available for it: relative to the instruction currently being
+// assembled ("$").
+func formatRelativeJump(offset int) string {
+	if offset >= 0 {
+		return "$+" + itoa(offset)
+	}
+	return "$" + itoa(offset)
+}
+
+// decodeFarDirect decodes call/jmp's direct far form (0x9A/0xEA):
+// a 16-bit offset followed by a 16-bit segment.
+func decodeFarDirect(name string, instruction []byte) Inst {
+	offset := uint16(instruction[1]) | uint16(instruction[2])<<8
+	segment := uint16(instruction[3]) | uint16(instruction[4])<<8
+	return Inst{Op: name, Args: []Arg{{Kind: ArgFar, FarSegment: segment, FarOffset: offset}}}
+}
+
+func itoa(n int) string {
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	if n == 0 {
+		return "0"
+	}
+	var digits [20]byte
+	i := len(digits)
+	for n > 0 {
+		i--
+		digits[i] = byte('0' + n%10)
+		n /= 10
+	}
+	if neg {
+		i--
+		digits[i] = '-'
+	}
+	return string(digits[i:])
+}
@@ -0,0 +1,188 @@
+package decoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Syntax selects how Format renders a decoded Inst as text, mirroring the
+// split x/arch's x86asm draws between GNUSyntax/GoSyntax/IntelSyntax and
+// Delve's AssemblyFlavour enum.
+type Syntax int
+
+const (
+	// SyntaxIntel and SyntaxNASM both render Intel-style operand order
+	// (destination, source) with bracketed memory operands; this
+	// decoder's operand set doesn't distinguish the two NASM-compatible
+	// dialects x86 tooling sometimes splits apart (e.g. MASM-isms), so
+	// they currently share a formatter.
+	SyntaxIntel Syntax = iota
+	SyntaxNASM
+	// SyntaxATT renders AT&T order (source, destination), %-prefixed
+	// registers, $-prefixed immediates, and mov[bw] size suffixes.
+	SyntaxATT
+)
+
+// Format renders inst as assembly text in the given syntax.
+func Format(inst Inst, syntax Syntax) string {
+	if syntax == SyntaxATT {
+		return FormatATT(inst)
+	}
+	return FormatIntel(inst)
+}
+
+// FormatIntel renders inst the way this package always has: mnemonic,
+// then comma-separated operands in destination-first order, memory
+// operands in brackets, and an explicit "word"/"byte" keyword on a memory
+// operand when no register operand already says which size is meant.
+func FormatIntel(inst Inst) string {
+	if len(inst.Args) == 0 {
+		return inst.Op
+	}
+
+	hasRegister := false
+	for _, a := range inst.Args {
+		if a.Kind == ArgReg {
+			hasRegister = true
+		}
+	}
+
+	operands := make([]string, len(inst.Args))
+	for i, a := range inst.Args {
+		operands[i] = formatIntelArg(a, inst.Wide, hasRegister)
+	}
+	return fmt.Sprintf("%s %s", inst.Op, strings.Join(operands, ", "))
+}
+
+func formatIntelArg(a Arg, wide bool, hasRegister bool) string {
+	switch a.Kind {
+	case ArgReg:
+		return a.Reg
+	case ArgImm:
+		return fmt.Sprintf("%v", a.Imm)
+	case ArgMem:
+		mem := formatMemExpr(a.Mem)
+		if hasRegister {
+			return mem
+		}
+		if wide {
+			return "word " + mem
+		}
+		return "byte " + mem
+	case ArgRel:
+		if a.Label != "" {
+			return a.Label
+		}
+		return formatRelativeJump(a.Rel)
+	case ArgFar:
+		return fmt.Sprintf("%v:%v", a.FarSegment, a.FarOffset)
+	}
+	return ""
+}
+
+func formatMemExpr(m MemArg) string {
+	if len(m.Regs) == 0 {
+		return fmt.Sprintf("[%v]", m.Disp)
+	}
+	expr := strings.Join(m.Regs, " + ")
+	if m.HasDisp {
+		expr = fmt.Sprintf("%s + %v", expr, m.Disp)
+	}
+	return fmt.Sprintf("[%s]", expr)
+}
+
+// FormatATT renders inst in AT&T order (source, destination) with
+// %-prefixed registers, $-prefixed immediates, and a mov[bw] size suffix
+// when no register operand already carries the size. The 8086 has no
+// scaled-index addressing, so a two-register memory operand like
+// "[bx + si]" maps onto AT&T's "(base,index)" form with no scale.
+func FormatATT(inst Inst) string {
+	if len(inst.Args) == 0 {
+		return inst.Op
+	}
+
+	hasRegister := false
+	for _, a := range inst.Args {
+		if a.Kind == ArgReg {
+			hasRegister = true
+		}
+	}
+
+	op := inst.Op
+	if !hasRegister {
+		if inst.Wide {
+			op += "w"
+		} else {
+			op += "b"
+		}
+	}
+
+	operands := make([]string, len(inst.Args))
+	for i, a := range inst.Args {
+		operands[i] = formatATTArg(a)
+	}
+	if isIndirectBranch(inst) {
+		operands[0] = "*" + operands[0]
+	}
+	reverseArgs(operands)
+	return fmt.Sprintf("%s %s", op, strings.Join(operands, ", "))
+}
+
+// isIndirectBranch reports whether inst is a group FE/FF call/jmp through
+// a register or memory r/m operand rather than a relative or far direct
+// target. GAS requires these to be marked with a leading "*"
+// ("jmp *%ax"); without it, "jmp %ax" isn't valid AT&T syntax and
+// "jmp ax" would mean a jump to a symbol named ax.
+func isIndirectBranch(inst Inst) bool {
+	if inst.Op != "call" && inst.Op != "jmp" {
+		return false
+	}
+	if len(inst.Args) != 1 {
+		return false
+	}
+	kind := inst.Args[0].Kind
+	return kind == ArgReg || kind == ArgMem
+}
+
+func formatATTArg(a Arg) string {
+	switch a.Kind {
+	case ArgReg:
+		return "%" + a.Reg
+	case ArgImm:
+		return fmt.Sprintf("$%v", a.Imm)
+	case ArgMem:
+		return formatATTMemExpr(a.Mem)
+	case ArgRel:
+		if a.Label != "" {
+			return a.Label
+		}
+		return formatRelativeJump(a.Rel)
+	case ArgFar:
+		return fmt.Sprintf("$%v, $%v", a.FarSegment, a.FarOffset)
+	}
+	return ""
+}
+
+func formatATTMemExpr(m MemArg) string {
+	if len(m.Regs) == 0 {
+		return fmt.Sprintf("%v", m.Disp)
+	}
+	disp := ""
+	if m.HasDisp {
+		disp = fmt.Sprintf("%v", m.Disp)
+	}
+	regs := make([]string, len(m.Regs))
+	for i, r := range m.Regs {
+		regs[i] = "%" + r
+	}
+	return fmt.Sprintf("%s(%s)", disp, strings.Join(regs, ","))
+}
+
+// reverseArgs flips operand order in place; AT&T syntax lists the source
+// before the destination, the opposite of this decoder's native
+// (Intel) order.
+func reverseArgs(operands []string) {
+	for i, j := 0, len(operands)-1; i < j; i, j = i+1, j-1 {
+		operands[i], operands[j] = operands[j], operands[i]
+	}
+}
@@ -0,0 +1,119 @@
+package decoder
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDecodeShapes exercises one representative opcode for each
+// instFormats shape except groupfe and accmemstore, checking that analyzeOpCode's mask/value matching (generated by
+// cmd/x86map from x86.csv) picks the right entry and that decode builds
+// the Inst it should.
+func TestDecodeShapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes []byte
+		want  Inst
+	}{
+		{
+			// Decode requires at least 2 buffered bytes even for a
+			// 1-byte instruction; pad with a second nop.
+			name:  "noop",
+			bytes: []byte{0x90, 0x90},
+			want:  Inst{Op: "nop"},
+		},
+		{
+			name:  "retimm",
+			bytes: []byte{0xC2, 0x04, 0x00},
+			want:  Inst{Op: "ret", Args: []Arg{{Kind: ArgImm, Imm: 4}}},
+		},
+		{
+			name:  "directjump call",
+			bytes: []byte{0xE8, 0x05, 0x00},
+			want:  Inst{Op: "call", Args: []Arg{{Kind: ArgRel, Rel: 8}}},
+		},
+		{
+			name:  "reljump",
+			bytes: []byte{0x74, 0x02},
+			want:  Inst{Op: "je", Args: []Arg{{Kind: ArgRel, Rel: 4}}},
+		},
+		{
+			name:  "fardirect",
+			bytes: []byte{0xEA, 0x00, 0x01, 0x00, 0x02},
+			want:  Inst{Op: "jmp", Args: []Arg{{Kind: ArgFar, FarSegment: 0x0200, FarOffset: 0x0100}}},
+		},
+		{
+			name:  "group1 sign-extended imm8",
+			bytes: []byte{0x83, 0xC0, 0xFF}, // add ax, -1
+			want:  Inst{Op: "add", Args: []Arg{{Kind: ArgReg, Reg: "ax"}, {Kind: ArgImm, Imm: -1}}, Wide: true},
+		},
+		{
+			name:  "groupff indirect jmp",
+			bytes: []byte{0xFF, 0xE0}, // jmp ax
+			want:  Inst{Op: "jmp", Args: []Arg{{Kind: ArgReg, Reg: "ax"}}, Wide: true},
+		},
+		{
+			name:  "popregmem",
+			bytes: []byte{0x8F, 0xC0}, // pop ax
+			want:  Inst{Op: "pop", Args: []Arg{{Kind: ArgReg, Reg: "ax"}}, Wide: true},
+		},
+		{
+			name:  "accmemload",
+			bytes: []byte{0xA1, 0x34, 0x12}, // mov ax, [0x1234]
+			want:  Inst{Op: "mov", Args: []Arg{{Kind: ArgReg, Reg: "ax"}, {Kind: ArgMem, Mem: MemArg{Disp: 0x1234, HasDisp: true}}}, Wide: true},
+		},
+		{
+			name:  "immtoregmem",
+			bytes: []byte{0xC7, 0xC0, 0x01, 0x00}, // mov ax, 1
+			want:  Inst{Op: "mov", Args: []Arg{{Kind: ArgReg, Reg: "ax"}, {Kind: ArgImm, Imm: 1}}, Wide: true},
+		},
+		{
+			name:  "xchgregmem",
+			bytes: []byte{0x87, 0xD8}, // xchg bx, ax
+			want:  Inst{Op: "xchg", Args: []Arg{{Kind: ArgReg, Reg: "bx"}, {Kind: ArgReg, Reg: "ax"}}, Wide: true},
+		},
+		{
+			name:  "immacc",
+			bytes: []byte{0x04, 0x02}, // add al, 2
+			want:  Inst{Op: "add", Args: []Arg{{Kind: ArgReg, Reg: "al"}, {Kind: ArgImm, Imm: 2}}},
+		},
+		{
+			name:  "modregrm",
+			bytes: []byte{0x01, 0xD8}, // add ax, bx
+			want:  Inst{Op: "add", Args: []Arg{{Kind: ArgReg, Reg: "ax"}, {Kind: ArgReg, Reg: "bx"}}, Wide: true},
+		},
+		{
+			name:  "reginop",
+			bytes: []byte{0x40, 0x90}, // inc ax
+			want:  Inst{Op: "inc", Args: []Arg{{Kind: ArgReg, Reg: "ax"}}, Wide: true},
+		},
+		{
+			name:  "xchgaxreg",
+			bytes: []byte{0x93, 0x90}, // xchg ax, bx
+			want:  Inst{Op: "xchg", Args: []Arg{{Kind: ArgReg, Reg: "ax"}, {Kind: ArgReg, Reg: "bx"}}, Wide: true},
+		},
+		{
+			name:  "immtoreg byte",
+			bytes: []byte{0xB1, 0x07}, // mov cl, 7
+			want:  Inst{Op: "mov", Args: []Arg{{Kind: ArgReg, Reg: "cl"}, {Kind: ArgImm, Imm: 7}}},
+		},
+		{
+			name:  "immtoreg word",
+			bytes: []byte{0xB9, 0x07, 0x00}, // mov cx, 7
+			want:  Inst{Op: "mov", Args: []Arg{{Kind: ArgReg, Reg: "cx"}, {Kind: ArgImm, Imm: 7}}, Wide: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoderBytes(tt.bytes)
+			instr, err := d.Decode()
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(instr.Inst, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", instr.Inst, tt.want)
+			}
+		})
+	}
+}
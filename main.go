@@ -1,21 +1,28 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
 	deco "github.com/jorovipe97/performance-aware-homework/decoder"
+	"github.com/jorovipe97/performance-aware-homework/simulator"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	exec := flag.Bool("exec", false, "execute the decoded instructions with the simulator instead of just disassembling them")
+	trace := flag.Bool("trace", false, "with -exec, print a register/flag trace for each instruction as it runs")
+	labels := flag.Bool("labels", false, "symbolize jump/call targets as label_XXXX instead of nasm's $+N, with labels declared before the instruction they point at")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
 		log.Fatal("The filename arg is required.")
 	}
-
-	fileName := os.Args[1]
+	fileName := flag.Arg(0)
 
 	// Get the working directory
 	wd, err := os.Getwd()
@@ -23,10 +30,27 @@ func main() {
 		log.Fatal(err)
 	}
 
-	path := filepath.Join(wd, "listings", fileName)
-	data, err := os.ReadFile(path)
-	if err != nil {
-		log.Fatal(err)
+	// "-" streams the program from stdin instead of reading a listings
+	// file, so large binaries can be disassembled without loading them
+	// fully into memory (decoder.NewDecoder). -labels and -exec both need
+	// random access to the whole program (rewinding to collect labels,
+	// seeking backward on jumps), so they stay on the in-memory path.
+	streaming := fileName == "-"
+	if streaming && (*labels || *exec) {
+		log.Fatal("-labels and -exec require random access and can't be used with \"-\" (stdin)")
+	}
+
+	var data []byte
+	var decoder *deco.Decoder
+	if streaming {
+		decoder = deco.NewDecoder(os.Stdin)
+	} else {
+		path := filepath.Join(wd, "listings", fileName)
+		data, err = os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		decoder = deco.NewDecoderBytes(data)
 	}
 
 	var builder strings.Builder
@@ -34,21 +58,32 @@ func main() {
 	// Tells assembler we intent to run assembly for old 8086 architecture.
 	builder.WriteString("bits 16\n")
 
-	decoder := deco.Decoder{
-		Data: data,
+	var table *deco.LabelTable
+	if *labels {
+		table, err = decoder.CollectLabels()
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
+
 	for {
-		if !decoder.HasNext() {
+		instr, err := decoder.Decode()
+		if err == io.EOF {
 			break
 		}
-
-		opcode, instr, err := decoder.Next()
 		if err != nil {
 			log.Print(err)
 			break
 		}
-		instrAsmString := decoder.AsmString(opcode, instr)
-		builder.WriteString(instrAsmString + "\n")
+
+		if table != nil {
+			if name, ok := table.LabelAt(instr.PC); ok {
+				builder.WriteString(name + ":\n")
+			}
+			builder.WriteString(decoder.AsmStringAt(instr, table.Resolve) + "\n")
+			continue
+		}
+		builder.WriteString(decoder.AsmString(instr) + "\n")
 	}
 
 	// Shows assembly code:
@@ -61,4 +96,13 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if *exec {
+		sim := simulator.New(data)
+		sim.Trace = *trace
+		if err := sim.Run(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(sim.DumpVideoMemory())
+	}
 }
@@ -0,0 +1,140 @@
+package simulator
+
+// Flags models the 8086 status flags this simulator tracks: Carry, Zero,
+// Sign, Parity and Overflow. Arithmetic and compare instructions update
+// all five with the standard 8086 semantics; mov, the logical group
+// (and/or/xor), and inc/dec update only the subset the manual specifies.
+type Flags struct {
+	Carry, Zero, Sign, Parity, Overflow bool
+}
+
+// String renders the set flags as the compact letter form Casey's
+// listings use in their expected trace output, e.g. "CZ" for
+// Carry+Zero, "" when none are set.
+func (f Flags) String() string {
+	letters := ""
+	if f.Carry {
+		letters += "C"
+	}
+	if f.Zero {
+		letters += "Z"
+	}
+	if f.Sign {
+		letters += "S"
+	}
+	if f.Overflow {
+		letters += "O"
+	}
+	if f.Parity {
+		letters += "P"
+	}
+	return letters
+}
+
+// parityEven reports whether the low byte of value has an even number of
+// set bits (the 8086 only ever computes parity over the low byte, even
+// for word results).
+func parityEven(value uint16) bool {
+	b := byte(value)
+	count := 0
+	for b != 0 {
+		count += int(b & 1)
+		b >>= 1
+	}
+	return count%2 == 0
+}
+
+func signBit(value uint16, wide bool) bool {
+	if wide {
+		return value&0x8000 != 0
+	}
+	return value&0x0080 != 0
+}
+
+func truncate(value uint16, wide bool) uint16 {
+	if wide {
+		return value
+	}
+	return value & 0x00FF
+}
+
+// updateAfterAdd sets flags for dst+src (ADD/ADC) producing result,
+// computed with one extra bit of headroom by the caller so the carry out
+// is still visible in result's bit 16 (or bit 8 for byte operations).
+func (s *Simulator) updateAfterAdd(dst, src uint16, result uint32, wide bool) {
+	mask := uint32(0xFFFF)
+	signMask := uint32(0x8000)
+	if !wide {
+		mask = 0xFF
+		signMask = 0x80
+	}
+
+	s.Flags.Carry = result&^mask != 0
+	truncated := uint16(result & mask)
+	s.Flags.Zero = truncated == 0
+	s.Flags.Sign = signBit(truncated, wide)
+	s.Flags.Parity = parityEven(truncated)
+
+	dstSign := signBit(truncate(dst, wide), wide)
+	srcSign := signBit(truncate(src, wide), wide)
+	resultSign := uint32(truncated)&signMask != 0
+	s.Flags.Overflow = dstSign == srcSign && resultSign != dstSign
+}
+
+// updateAfterSub sets flags for dst-src (SUB/SBB/CMP).
+func (s *Simulator) updateAfterSub(dst, src uint16, result uint32, wide bool) {
+	mask := uint32(0xFFFF)
+	signMask := uint32(0x8000)
+	if !wide {
+		mask = 0xFF
+		signMask = 0x80
+	}
+
+	s.Flags.Carry = result&^mask != 0
+	truncated := uint16(result & mask)
+	s.Flags.Zero = truncated == 0
+	s.Flags.Sign = signBit(truncated, wide)
+	s.Flags.Parity = parityEven(truncated)
+
+	dstSign := signBit(truncate(dst, wide), wide)
+	srcSign := signBit(truncate(src, wide), wide)
+	resultSign := uint32(truncated)&signMask != 0
+	s.Flags.Overflow = dstSign != srcSign && resultSign != dstSign
+}
+
+// updateLogic sets flags for and/or/xor: carry and overflow are always
+// cleared, the rest follow the result.
+func (s *Simulator) updateLogic(result uint16, wide bool) {
+	truncated := truncate(result, wide)
+	s.Flags.Carry = false
+	s.Flags.Overflow = false
+	s.Flags.Zero = truncated == 0
+	s.Flags.Sign = signBit(truncated, wide)
+	s.Flags.Parity = parityEven(truncated)
+}
+
+// updateIncDec sets flags for inc/dec: carry is left untouched, matching
+// the 8086 manual. isInc distinguishes the only two values that can
+// overflow: the most positive value wrapping negative on inc, or the
+// most negative value wrapping positive on dec.
+func (s *Simulator) updateIncDec(before uint16, wide bool, isInc bool) {
+	delta := uint16(1)
+	if !isInc {
+		delta = 0xFFFF // -1
+	}
+	after := truncate(before+delta, wide)
+
+	s.Flags.Zero = after == 0
+	s.Flags.Sign = signBit(after, wide)
+	s.Flags.Parity = parityEven(after)
+
+	maxPositive, maxNegative := uint16(0x7FFF), uint16(0x8000)
+	if !wide {
+		maxPositive, maxNegative = 0x7F, 0x80
+	}
+	if isInc {
+		s.Flags.Overflow = truncate(before, wide) == maxPositive
+	} else {
+		s.Flags.Overflow = truncate(before, wide) == maxNegative
+	}
+}
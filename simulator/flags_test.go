@@ -0,0 +1,116 @@
+package simulator
+
+import "testing"
+
+func TestUpdateAfterAdd(t *testing.T) {
+	tests := []struct {
+		name                                        string
+		dst, src                                    uint16
+		wide                                        bool
+		wantZero, wantSign, wantCarry, wantOverflow bool
+	}{
+		{name: "word no flags", dst: 1, src: 1, wide: true},
+		{name: "word zero", dst: 0xFFFF, src: 1, wide: true, wantZero: true, wantCarry: true},
+		{name: "word signed overflow", dst: 0x7FFF, src: 1, wide: true, wantSign: true, wantOverflow: true},
+		{name: "byte carry no word carry", dst: 0xFF, src: 1, wide: false, wantZero: true, wantCarry: true},
+		{name: "byte signed overflow", dst: 0x7F, src: 1, wide: false, wantSign: true, wantOverflow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Simulator{}
+			result := uint32(tt.dst) + uint32(tt.src)
+			s.updateAfterAdd(tt.dst, tt.src, result, tt.wide)
+			if s.Flags.Zero != tt.wantZero {
+				t.Errorf("Zero = %v, want %v", s.Flags.Zero, tt.wantZero)
+			}
+			if s.Flags.Sign != tt.wantSign {
+				t.Errorf("Sign = %v, want %v", s.Flags.Sign, tt.wantSign)
+			}
+			if s.Flags.Carry != tt.wantCarry {
+				t.Errorf("Carry = %v, want %v", s.Flags.Carry, tt.wantCarry)
+			}
+			if s.Flags.Overflow != tt.wantOverflow {
+				t.Errorf("Overflow = %v, want %v", s.Flags.Overflow, tt.wantOverflow)
+			}
+		})
+	}
+}
+
+func TestUpdateAfterSub(t *testing.T) {
+	tests := []struct {
+		name                                        string
+		dst, src                                    uint16
+		wide                                        bool
+		wantZero, wantSign, wantCarry, wantOverflow bool
+	}{
+		{name: "equal values", dst: 5, src: 5, wide: true, wantZero: true},
+		{name: "borrow", dst: 0, src: 1, wide: true, wantSign: true, wantCarry: true},
+		{name: "signed overflow", dst: 0x8000, src: 1, wide: true, wantOverflow: true},
+		{name: "byte borrow", dst: 0, src: 1, wide: false, wantSign: true, wantCarry: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Simulator{}
+			result := uint32(tt.dst) - uint32(tt.src)
+			s.updateAfterSub(tt.dst, tt.src, result, tt.wide)
+			if s.Flags.Zero != tt.wantZero {
+				t.Errorf("Zero = %v, want %v", s.Flags.Zero, tt.wantZero)
+			}
+			if s.Flags.Sign != tt.wantSign {
+				t.Errorf("Sign = %v, want %v", s.Flags.Sign, tt.wantSign)
+			}
+			if s.Flags.Carry != tt.wantCarry {
+				t.Errorf("Carry = %v, want %v", s.Flags.Carry, tt.wantCarry)
+			}
+			if s.Flags.Overflow != tt.wantOverflow {
+				t.Errorf("Overflow = %v, want %v", s.Flags.Overflow, tt.wantOverflow)
+			}
+		})
+	}
+}
+
+func TestUpdateLogicClearsCarryAndOverflow(t *testing.T) {
+	s := &Simulator{Flags: Flags{Carry: true, Overflow: true}}
+	s.updateLogic(0, true)
+	if s.Flags.Carry || s.Flags.Overflow {
+		t.Errorf("and/or/xor must clear carry and overflow, got Carry=%v Overflow=%v", s.Flags.Carry, s.Flags.Overflow)
+	}
+	if !s.Flags.Zero {
+		t.Error("Zero should be set for a zero result")
+	}
+}
+
+func TestUpdateIncDecOverflow(t *testing.T) {
+	s := &Simulator{}
+	s.updateIncDec(0x7FFF, true, true) // inc from the most positive word value
+	if !s.Flags.Overflow {
+		t.Error("inc from 0x7FFF should overflow")
+	}
+
+	s = &Simulator{}
+	s.updateIncDec(0x8000, true, false) // dec from the most negative word value
+	if !s.Flags.Overflow {
+		t.Error("dec from 0x8000 should overflow")
+	}
+}
+
+func TestParityEven(t *testing.T) {
+	tests := []struct {
+		value uint16
+		want  bool
+	}{
+		{value: 0x00, want: true},   // 0 bits set
+		{value: 0x01, want: false},  // 1 bit set
+		{value: 0x03, want: true},   // 2 bits set
+		{value: 0xFF, want: true},   // 8 bits set
+		{value: 0xFF00, want: true}, // parity only looks at the low byte
+	}
+
+	for _, tt := range tests {
+		if got := parityEven(tt.value); got != tt.want {
+			t.Errorf("parityEven(0x%X) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
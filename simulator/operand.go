@@ -0,0 +1,78 @@
+package simulator
+
+import (
+	deco "github.com/jorovipe97/performance-aware-homework/decoder"
+)
+
+// evalAddress computes a memory operand's effective address from its
+// base/index registers plus displacement, e.g. "[bx + si]" or a bare
+// direct address like "[1000]".
+func (s *Simulator) evalAddress(mem deco.MemArg) uint16 {
+	addr := mem.Disp
+	for _, reg := range mem.Regs {
+		addr += int(s.Registers.getRegister(reg))
+	}
+	return uint16(addr)
+}
+
+// getOperand reads an operand's value, whichever form it takes: register,
+// memory, or immediate.
+func (s *Simulator) getOperand(arg deco.Arg, wide bool) uint16 {
+	switch arg.Kind {
+	case deco.ArgReg:
+		return s.Registers.getRegister(arg.Reg)
+	case deco.ArgMem:
+		return s.readMemory(s.evalAddress(arg.Mem), wide)
+	case deco.ArgImm:
+		return uint16(arg.Imm)
+	}
+	return 0
+}
+
+// setOperand writes an operand's value; immediates are never a valid
+// destination, so only the register and memory forms are handled.
+func (s *Simulator) setOperand(arg deco.Arg, value uint16, wide bool) {
+	switch arg.Kind {
+	case deco.ArgReg:
+		s.Registers.setRegister(arg.Reg, value)
+	case deco.ArgMem:
+		s.writeMemory(s.evalAddress(arg.Mem), value, wide)
+	}
+}
+
+func (s *Simulator) readMemory(address uint16, wide bool) uint16 {
+	if !wide {
+		return uint16(s.Memory[address])
+	}
+	return uint16(s.Memory[address]) | uint16(s.Memory[address+1])<<8
+}
+
+func (s *Simulator) writeMemory(address uint16, value uint16, wide bool) {
+	if !wide {
+		s.Memory[address] = byte(value)
+		return
+	}
+	s.Memory[address] = byte(value)
+	s.Memory[address+1] = byte(value >> 8)
+}
+
+// jumpTarget resolves a call/jmp/conditional-jump operand to the absolute
+// address it redirects to. ArgRel is the relative form the decoder emits
+// for near jumps with no symbol table (nasm's "$+N"), resolved against the
+// address the jumping instruction started at. ArgReg/ArgMem are the
+// indirect form group FF opcodes use (e.g. "jmp ax", "call word [bx]"):
+// the target is whatever value the register/memory operand holds. ArgFar
+// is the far call/jmp form (0x9A/0xEA); like retf's saved segment, its
+// segment half is discarded - segments aren't modeled, so FarOffset alone
+// is the target.
+func (s *Simulator) jumpTarget(arg deco.Arg, instructionStart int) (int, bool) {
+	switch arg.Kind {
+	case deco.ArgRel:
+		return instructionStart + arg.Rel, true
+	case deco.ArgReg, deco.ArgMem:
+		return int(s.getOperand(arg, true)), true
+	case deco.ArgFar:
+		return int(arg.FarOffset), true
+	}
+	return 0, false
+}
@@ -0,0 +1,60 @@
+package simulator
+
+// Registers models the 8086's 8 general-purpose registers as a single
+// array of words, indexed the same way decoder.RegisterW1 does (AX=0,
+// CX=1, DX=2, BX=3, SP=4, BP=5, SI=6, DI=7). AX/CX/DX/BX additionally
+// expose their low and high bytes as AL/AH etc.
+type Registers [8]uint16
+
+const (
+	regAX = 0
+	regCX = 1
+	regDX = 2
+	regBX = 3
+	regSP = 4
+	regBP = 5
+	regSI = 6
+	regDI = 7
+)
+
+var wordRegisterNames = map[string]int{
+	"ax": regAX, "cx": regCX, "dx": regDX, "bx": regBX,
+	"sp": regSP, "bp": regBP, "si": regSI, "di": regDI,
+}
+
+// lowByteRegisterNames/highByteRegisterNames only exist for AX/CX/DX/BX:
+// SP/BP/SI/DI have no byte-sized form on the 8086.
+var lowByteRegisterNames = map[string]int{"al": regAX, "cl": regCX, "dl": regDX, "bl": regBX}
+var highByteRegisterNames = map[string]int{"ah": regAX, "ch": regCX, "dh": regDX, "bh": regBX}
+
+// getRegister reads a register by its assembly name, handling the
+// low/high byte aliases of AX/CX/DX/BX.
+func (r Registers) getRegister(name string) uint16 {
+	if idx, ok := wordRegisterNames[name]; ok {
+		return r[idx]
+	}
+	if idx, ok := lowByteRegisterNames[name]; ok {
+		return r[idx] & 0x00FF
+	}
+	if idx, ok := highByteRegisterNames[name]; ok {
+		return r[idx] >> 8
+	}
+	return 0
+}
+
+// setRegister writes a register by its assembly name. Writing a byte
+// alias only touches its half of the containing word register.
+func (r *Registers) setRegister(name string, value uint16) {
+	if idx, ok := wordRegisterNames[name]; ok {
+		r[idx] = value
+		return
+	}
+	if idx, ok := lowByteRegisterNames[name]; ok {
+		r[idx] = (r[idx] & 0xFF00) | (value & 0x00FF)
+		return
+	}
+	if idx, ok := highByteRegisterNames[name]; ok {
+		r[idx] = (r[idx] & 0x00FF) | ((value & 0x00FF) << 8)
+		return
+	}
+}
@@ -0,0 +1,315 @@
+// Package simulator executes the instruction stream decoder.Decoder
+// produces, modeling the subset of an 8086 CPU needed to run Casey
+// Muratori's "Performance-Aware Programming" example listings: the 8
+// general registers, a flat 64 KiB memory, and the status flags
+// arithmetic/compare instructions update.
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	deco "github.com/jorovipe97/performance-aware-homework/decoder"
+)
+
+// MemorySize is the full 64 KiB address space a flat, unsegmented 8086
+// can address - segment registers aren't modeled, so every address is
+// assumed to already be relative to segment zero.
+const MemorySize = 1 << 16
+
+// Simulator executes a decoded 8086 instruction stream in place: it reads
+// instructions from a decoder.Decoder over the program bytes, and stores
+// data separately in Memory. A real 8086 keeps code and the data it
+// touches in the same address space, but the listings in this course
+// never read their own code as data.
+type Simulator struct {
+	Registers Registers
+	Memory    [MemorySize]byte
+	Flags     Flags
+
+	// Halted becomes true once a hlt instruction executes; Run stops
+	// stepping once it is set.
+	Halted bool
+
+	// Trace, when true, makes Step print a line per executed instruction
+	// in the form "mov ax, 1 ; ax:0x0->0x1 ip:0x0->0x3" to Out.
+	Trace bool
+	Out   io.Writer
+
+	dec *deco.Decoder
+}
+
+// New returns a Simulator ready to execute program, starting at IP 0.
+func New(program []byte) *Simulator {
+	return &Simulator{
+		dec: deco.NewDecoderBytes(program),
+		Out: os.Stdout,
+	}
+}
+
+// IP reports the address of the next instruction to execute.
+func (s *Simulator) IP() uint16 {
+	return uint16(s.dec.Pos())
+}
+
+// Run steps the simulator until the program runs out of instructions, a
+// hlt executes, or an instruction fails to decode.
+func (s *Simulator) Run() error {
+	for {
+		more, err := s.Step()
+		if err != nil {
+			return err
+		}
+		if !more || s.Halted {
+			return nil
+		}
+	}
+}
+
+// Step decodes and executes a single instruction, reporting whether
+// there was one left to run.
+func (s *Simulator) Step() (bool, error) {
+	instructionStart := s.dec.Pos()
+	instr, err := s.dec.Decode()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("simulator: decoding instruction at 0x%X: %w", instructionStart, err)
+	}
+	asm := s.dec.AsmString(instr)
+
+	var before Registers
+	var beforeFlags Flags
+	if s.Trace {
+		before, beforeFlags = s.Registers, s.Flags
+	}
+
+	nextIP := s.dec.Pos() // the decoder has already advanced past instr.
+	if err := s.execute(instr, instructionStart, &nextIP); err != nil {
+		return false, fmt.Errorf("simulator: executing instruction at 0x%X: %w", instructionStart, err)
+	}
+	if err := s.dec.Seek(nextIP); err != nil {
+		// Simulator always runs off NewDecoderBytes, which Seek never
+		// rejects; a failure here means New was given a streaming decoder.
+		return false, fmt.Errorf("simulator: %w", err)
+	}
+
+	if s.Trace {
+		s.printTrace(asm, before, beforeFlags, instructionStart)
+	}
+
+	return true, nil
+}
+
+// execute interprets one decoded instruction. nextIP starts out pointing
+// just past the instruction (the fall-through address); jump, call and
+// ret are the only mnemonics that redirect it elsewhere. It returns an
+// error rather than silently leaving the instruction's effect unapplied,
+// whether that's a mnemonic this simulator doesn't model at all or a jump
+// whose target jumpTarget couldn't resolve.
+func (s *Simulator) execute(instr deco.Instruction, instructionStart int, nextIP *int) error {
+	mnemonic, args, wide := instr.Op, instr.Args, instr.Wide
+
+	switch mnemonic {
+	case "mov":
+		s.setOperand(args[0], s.getOperand(args[1], wide), wide)
+
+	case "add", "adc":
+		s.arith(args, wide, func(dst, src uint32) uint32 {
+			if mnemonic == "adc" && s.Flags.Carry {
+				src++
+			}
+			return dst + src
+		}, s.updateAfterAdd)
+
+	case "sub", "sbb", "cmp":
+		dst := s.getOperand(args[0], wide)
+		src := s.getOperand(args[1], wide)
+		borrow := uint32(0)
+		if mnemonic == "sbb" && s.Flags.Carry {
+			borrow = 1
+		}
+		result := uint32(dst) - uint32(src) - borrow
+		s.updateAfterSub(dst, src, result, wide)
+		if mnemonic != "cmp" {
+			s.setOperand(args[0], uint16(result), wide)
+		}
+
+	case "and", "or", "xor":
+		dst := s.getOperand(args[0], wide)
+		src := s.getOperand(args[1], wide)
+		var result uint16
+		switch mnemonic {
+		case "and":
+			result = dst & src
+		case "or":
+			result = dst | src
+		case "xor":
+			result = dst ^ src
+		}
+		s.setOperand(args[0], result, wide)
+		s.updateLogic(result, wide)
+
+	case "inc", "dec":
+		before := s.getOperand(args[0], wide)
+		delta := uint16(1)
+		if mnemonic == "dec" {
+			delta = 0xFFFF
+		}
+		s.setOperand(args[0], before+delta, wide)
+		s.updateIncDec(before, wide, mnemonic == "inc")
+
+	case "push":
+		s.push(s.getOperand(args[0], true))
+	case "pop":
+		s.setOperand(args[0], s.pop(), true)
+	case "xchg":
+		a, b := s.getOperand(args[0], wide), s.getOperand(args[1], wide)
+		s.setOperand(args[0], b, wide)
+		s.setOperand(args[1], a, wide)
+
+	case "call":
+		target, ok := s.jumpTarget(args[0], instructionStart)
+		if !ok {
+			return fmt.Errorf("call: unresolvable jump target %+v", args[0])
+		}
+		s.push(uint16(*nextIP))
+		*nextIP = target
+	case "ret":
+		*nextIP = int(s.pop())
+		if len(args) == 1 {
+			s.Registers[regSP] += uint16(args[0].Imm)
+		}
+	case "retf":
+		*nextIP = int(s.pop())
+		s.pop() // discard the saved code segment; segments aren't modeled.
+
+	case "jmp":
+		target, ok := s.jumpTarget(args[0], instructionStart)
+		if !ok {
+			return fmt.Errorf("jmp: unresolvable jump target %+v", args[0])
+		}
+		*nextIP = target
+
+	case "je", "jne", "jl", "jnl", "jle", "jg", "jb", "jnb", "jbe", "ja",
+		"jo", "jno", "js", "jns", "jp", "jnp":
+		if s.conditionMet(mnemonic) {
+			target, ok := s.jumpTarget(args[0], instructionStart)
+			if !ok {
+				return fmt.Errorf("%s: unresolvable jump target %+v", mnemonic, args[0])
+			}
+			*nextIP = target
+		}
+
+	case "loop", "loopz", "loopnz", "jcxz":
+		s.Registers[regCX]--
+		taken := false
+		switch mnemonic {
+		case "loop":
+			taken = s.Registers[regCX] != 0
+		case "loopz":
+			taken = s.Registers[regCX] != 0 && s.Flags.Zero
+		case "loopnz":
+			taken = s.Registers[regCX] != 0 && !s.Flags.Zero
+		case "jcxz":
+			s.Registers[regCX]++ // jcxz doesn't touch cx; undo the decrement above.
+			taken = s.Registers[regCX] == 0
+		}
+		if taken {
+			target, ok := s.jumpTarget(args[0], instructionStart)
+			if !ok {
+				return fmt.Errorf("%s: unresolvable jump target %+v", mnemonic, args[0])
+			}
+			*nextIP = target
+		}
+
+	case "hlt":
+		s.Halted = true
+
+	case "nop", "clc", "stc":
+		// clc/stc are accepted but leave Carry untouched: this simulator
+		// doesn't yet model every flag instruction, only what the
+		// arithmetic/compare/jump families need to be testable.
+
+	default:
+		return fmt.Errorf("unhandled instruction %q", mnemonic)
+	}
+	return nil
+}
+
+// arith runs add (and adc, via combine folding the carry in) against
+// args, then applies the resulting flags.
+func (s *Simulator) arith(args []deco.Arg, wide bool, combine func(dst, src uint32) uint32, updateFlags func(dst, src uint16, result uint32, wide bool)) {
+	dst := s.getOperand(args[0], wide)
+	src := s.getOperand(args[1], wide)
+	result := combine(uint32(dst), uint32(src))
+	updateFlags(dst, src, result, wide)
+	s.setOperand(args[0], uint16(result), wide)
+}
+
+func (s *Simulator) push(value uint16) {
+	s.Registers[regSP] -= 2
+	s.writeMemory(s.Registers[regSP], value, true)
+}
+
+func (s *Simulator) pop() uint16 {
+	value := s.readMemory(s.Registers[regSP], true)
+	s.Registers[regSP] += 2
+	return value
+}
+
+func (s *Simulator) conditionMet(mnemonic string) bool {
+	f := s.Flags
+	switch mnemonic {
+	case "je":
+		return f.Zero
+	case "jne":
+		return !f.Zero
+	case "jl":
+		return f.Sign != f.Overflow
+	case "jnl":
+		return f.Sign == f.Overflow
+	case "jle":
+		return f.Zero || f.Sign != f.Overflow
+	case "jg":
+		return !f.Zero && f.Sign == f.Overflow
+	case "jb":
+		return f.Carry
+	case "jnb":
+		return !f.Carry
+	case "jbe":
+		return f.Carry || f.Zero
+	case "ja":
+		return !f.Carry && !f.Zero
+	case "jo":
+		return f.Overflow
+	case "jno":
+		return !f.Overflow
+	case "js":
+		return f.Sign
+	case "jns":
+		return !f.Sign
+	case "jp":
+		return f.Parity
+	case "jnp":
+		return !f.Parity
+	}
+	return false
+}
+
+func (s *Simulator) printTrace(asm string, before Registers, beforeFlags Flags, instructionStart int) {
+	diff := ""
+	names := [8]string{"ax", "cx", "dx", "bx", "sp", "bp", "si", "di"}
+	for i, name := range names {
+		if before[i] != s.Registers[i] {
+			diff += fmt.Sprintf(" %s:0x%x->0x%x", name, before[i], s.Registers[i])
+		}
+	}
+	if beforeFlags != s.Flags {
+		diff += fmt.Sprintf(" flags:%s->%s", beforeFlags, s.Flags)
+	}
+	diff += fmt.Sprintf(" ip:0x%x->0x%x", instructionStart, s.dec.Pos())
+	fmt.Fprintf(s.Out, "%s ;%s\n", asm, diff)
+}
@@ -0,0 +1,151 @@
+package simulator
+
+import (
+	"strings"
+	"testing"
+
+	deco "github.com/jorovipe97/performance-aware-homework/decoder"
+)
+
+// TestRunArithChain exercises mov/add/sub in sequence, the simplest path
+// through Step/execute that isn't covered by flags_test.go's direct calls
+// into the flag helpers.
+func TestRunArithChain(t *testing.T) {
+	program := []byte{
+		0xB8, 0x05, 0x00, // mov ax, 5
+		0x83, 0xC0, 0x03, // add ax, 3
+		0x83, 0xE8, 0x02, // sub ax, 2
+		0xF4, 0x90, // hlt (padded so peek has >=2 bytes)
+	}
+	s := New(program)
+	if err := s.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := s.Registers[regAX]; got != 6 {
+		t.Errorf("ax = %d, want 6", got)
+	}
+	if !s.Halted {
+		t.Error("expected Halted after hlt")
+	}
+}
+
+// TestRunIndirectJmp is the bug the maintainer review caught: an
+// indirect jmp through a register (group FF) used to be silently
+// dropped instead of redirecting the instruction stream.
+func TestRunIndirectJmp(t *testing.T) {
+	program := []byte{
+		0xB8, 0x08, 0x00, // mov ax, 8 (the offset of "mov cx, 2222" below)
+		0xFF, 0xE0, // jmp ax
+		0xBB, 0x57, 0x04, // mov bx, 1111 (must be skipped)
+		0xB9, 0xAE, 0x08, // mov cx, 2222
+		0xF4, 0x90, // hlt
+	}
+	s := New(program)
+	if err := s.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := s.Registers[regBX]; got != 0 {
+		t.Errorf("bx = %d, want 0 (mov bx should have been jumped over)", got)
+	}
+	if got := s.Registers[regCX]; got != 2222 {
+		t.Errorf("cx = %d, want 2222", got)
+	}
+}
+
+// TestRunFarJmp covers the far call/jmp form (0xEA), whose jumpTarget
+// case the review also flagged as silently declining to resolve.
+func TestRunFarJmp(t *testing.T) {
+	program := []byte{
+		0xEA, 0x07, 0x00, 0x00, 0x00, // jmp far 0x0000:0x0007
+		0x90, 0x90, // filler, skipped
+		0xBB, 0x37, 0x00, // mov bx, 55
+		0xF4, 0x90, // hlt
+	}
+	s := New(program)
+	if err := s.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := s.Registers[regBX]; got != 55 {
+		t.Errorf("bx = %d, want 55", got)
+	}
+}
+
+// TestRunConditionalJump exercises cmp + je together: the comparison
+// sets Zero and je consumes it to skip the instruction right after it.
+func TestRunConditionalJump(t *testing.T) {
+	program := []byte{
+		0xB8, 0x01, 0x00, // mov ax, 1
+		0x39, 0xC0, // cmp ax, ax
+		0x74, 0x03, // je +3 (skip the 3-byte mov bx below)
+		0xBB, 0x63, 0x00, // mov bx, 99 (must be skipped)
+		0xB9, 0x2A, 0x00, // mov cx, 42
+		0xF4, 0x90, // hlt
+	}
+	s := New(program)
+	if err := s.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !s.Flags.Zero {
+		t.Error("cmp ax, ax should set Zero")
+	}
+	if got := s.Registers[regBX]; got != 0 {
+		t.Errorf("bx = %d, want 0 (mov bx should have been jumped over)", got)
+	}
+	if got := s.Registers[regCX]; got != 42 {
+		t.Errorf("cx = %d, want 42", got)
+	}
+}
+
+// TestRunCallRet exercises call/ret together with the push/pop they're
+// built on: the return address call pushes must be what ret pops.
+func TestRunCallRet(t *testing.T) {
+	program := []byte{
+		0xE8, 0x05, 0x00, // call +5 (to offset 8, the subroutine below)
+		0xB9, 0x05, 0x00, // mov cx, 5 (runs after the call returns)
+		0xF4, 0x90, // hlt
+		0xBA, 0x4D, 0x00, // mov dx, 77
+		0xC3, 0x90, // ret
+	}
+	s := New(program)
+	if err := s.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := s.Registers[regDX]; got != 77 {
+		t.Errorf("dx = %d, want 77", got)
+	}
+	if got := s.Registers[regCX]; got != 5 {
+		t.Errorf("cx = %d, want 5", got)
+	}
+	if !s.Halted {
+		t.Error("expected Halted after hlt")
+	}
+}
+
+// TestRunUnhandledMnemonic covers the execute default case the review
+// asked for: an instruction this simulator doesn't model must surface as
+// an error, not silently do nothing.
+func TestRunUnhandledMnemonic(t *testing.T) {
+	s := New(nil)
+	nextIP := 0
+	err := s.execute(deco.Instruction{Inst: deco.Inst{Op: "movsb"}}, 0, &nextIP)
+	if err == nil {
+		t.Fatal("execute() error = nil, want an error for an unmodeled mnemonic")
+	}
+	if !strings.Contains(err.Error(), "movsb") {
+		t.Errorf("execute() error = %v, want it to name the unhandled mnemonic", err)
+	}
+}
+
+// TestRunUnresolvableJumpTarget covers a jmp whose operand jumpTarget
+// can't resolve (e.g. an immediate, which none of the 8086 jump/call
+// encodings actually produce): execute must report it instead of
+// quietly leaving nextIP at the fall-through address.
+func TestRunUnresolvableJumpTarget(t *testing.T) {
+	s := New(nil)
+	nextIP := 2
+	instr := deco.Instruction{Inst: deco.Inst{Op: "jmp", Args: []deco.Arg{{Kind: deco.ArgImm, Imm: 4}}}}
+	err := s.execute(instr, 0, &nextIP)
+	if err == nil {
+		t.Fatal("execute() error = nil, want an error for an unresolvable jump target")
+	}
+}
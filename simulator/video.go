@@ -0,0 +1,32 @@
+package simulator
+
+import "strings"
+
+// Several of the example listings write ASCII into memory starting at
+// 0x8000 to simulate "drawing" to a screen, the way classic PC text-mode
+// video RAM packs an 80x25 grid of character+attribute byte pairs into
+// 4000 (0x8000..0x8FA0) bytes.
+const (
+	videoMemoryBase   = 0x8000
+	videoMemoryWidth  = 80
+	videoMemoryHeight = 25
+)
+
+// DumpVideoMemory renders the character byte of each video RAM cell as an
+// 80x25 grid of text, so a listing that wrote ASCII into that region
+// produces visible output.
+func (s *Simulator) DumpVideoMemory() string {
+	var b strings.Builder
+	for row := 0; row < videoMemoryHeight; row++ {
+		for col := 0; col < videoMemoryWidth; col++ {
+			offset := videoMemoryBase + (row*videoMemoryWidth+col)*2
+			ch := s.Memory[offset]
+			if ch == 0 {
+				ch = ' '
+			}
+			b.WriteByte(ch)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}